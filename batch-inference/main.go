@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const defaultRegion = "us-east-1"
+
+const claudeV2ModelID = "anthropic.claude-v2"
+
+var bc *bedrock.Client
+var s3c *s3.Client
+
+func init() {
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bc = bedrock.NewFromConfig(cfg)
+	s3c = s3.NewFromConfig(cfg)
+}
+
+func main() {
+
+	usage := "usage: go run . prepare|submit|list|status|stop|wait|results [flags]"
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	args := os.Args[2:]
+
+	switch os.Args[1] {
+	case "prepare":
+		prepare(args)
+	case "submit":
+		submit(args)
+	case "list":
+		list(args)
+	case "status":
+		status(args)
+	case "stop":
+		stop(args)
+	case "wait":
+		wait(args)
+	case "results":
+		results(args)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// prepare converts a prompts file into a batch input file without submitting
+// a job, e.g. to inspect it or upload it separately.
+func prepare(args []string) {
+
+	fs := flag.NewFlagSet("prepare", flag.ExitOnError)
+	promptsFile := fs.String("prompts", "", "JSONL file with one {\"id\", \"prompt\", \"max_tokens\", \"temperature\"} object per line")
+	modelID := fs.String("model", claudeV2ModelID, "ID of the model the input is prepared for")
+	location := fs.String("input", ".", "local directory or s3://bucket/prefix to write the input file to")
+	name := fs.String("name", "batch-input", "name of the input file (without extension)")
+	fs.Parse(args)
+
+	key, err := writeInput(*promptsFile, *modelID, *location, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("wrote batch input to", key)
+}
+
+func writeInput(promptsFile, modelID, location, name string) (string, error) {
+
+	if promptsFile == "" {
+		return "", fmt.Errorf("prompts file is required")
+	}
+
+	family, err := models.FamilyOf(modelID)
+	if err != nil {
+		return "", err
+	}
+
+	prompts, err := readPrompts(promptsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompts: %w", err)
+	}
+
+	input, err := buildInput(family, prompts)
+	if err != nil {
+		return "", err
+	}
+
+	storage, err := newStorage(location)
+	if err != nil {
+		return "", err
+	}
+
+	key := name + ".jsonl"
+	err = storage.Put(context.Background(), key, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to write batch input: %w", err)
+	}
+
+	return storage.URI(key), nil
+}
+
+// submit uploads the batch input file and creates a model invocation job.
+func submit(args []string) {
+
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	promptsFile := fs.String("prompts", "", "JSONL file with one {\"id\", \"prompt\", \"max_tokens\", \"temperature\"} object per line")
+	modelID := fs.String("model", claudeV2ModelID, "ID of the model to invoke")
+	input := fs.String("input", "", "s3://bucket/prefix to upload the input file to")
+	output := fs.String("output", "", "s3://bucket/prefix for the job output")
+	role := fs.String("role", "", "ARN of the service role Bedrock uses to access the S3 locations")
+	name := fs.String("name", "", "name of the job (also used for the input file)")
+	timeout := fs.Int("timeout-hours", 0, "hours after which the job times out (service default if 0)")
+	fs.Parse(args)
+
+	if *name == "" || *role == "" {
+		log.Fatal("name and role are required")
+	}
+
+	if !strings.HasPrefix(*input, "s3://") || !strings.HasPrefix(*output, "s3://") {
+		log.Fatal("input and output must be S3 locations")
+	}
+
+	inputURI, err := writeInput(*promptsFile, *modelID, *input, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	req := &bedrock.CreateModelInvocationJobInput{
+		JobName: aws.String(*name),
+		ModelId: aws.String(*modelID),
+		RoleArn: aws.String(*role),
+		InputDataConfig: &types.ModelInvocationJobInputDataConfigMemberS3InputDataConfig{
+			Value: types.ModelInvocationJobS3InputDataConfig{S3Uri: aws.String(inputURI)},
+		},
+		OutputDataConfig: &types.ModelInvocationJobOutputDataConfigMemberS3OutputDataConfig{
+			Value: types.ModelInvocationJobS3OutputDataConfig{S3Uri: aws.String(*output)},
+		},
+	}
+
+	if *timeout > 0 {
+		req.TimeoutDurationInHours = aws.Int32(int32(*timeout))
+	}
+
+	out, err := bc.CreateModelInvocationJob(context.Background(), req)
+	if err != nil {
+		fmt.Println("failed to create model invocation job")
+		log.Fatal(err)
+	}
+
+	fmt.Println("created model invocation job", aws.ToString(out.JobArn))
+}
+
+func list(args []string) {
+
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	status := fs.String("status", "", "only list jobs with this status (e.g. InProgress, Completed, Failed)")
+	nameContains := fs.String("name-contains", "", "only list jobs whose name contains this string")
+	jsonOutput := fs.Bool("json", false, "print the jobs as JSON")
+	fs.Parse(args)
+
+	input := &bedrock.ListModelInvocationJobsInput{
+		StatusEquals: types.ModelInvocationJobStatus(*status),
+	}
+	if *nameContains != "" {
+		input.NameContains = aws.String(*nameContains)
+	}
+
+	var summaries []types.ModelInvocationJobSummary
+
+	p := bedrock.NewListModelInvocationJobsPaginator(bc, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.Background())
+		if err != nil {
+			fmt.Println("failed to list model invocation jobs")
+			log.Fatal(err)
+		}
+		summaries = append(summaries, page.InvocationJobSummaries...)
+	}
+
+	if *jsonOutput {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tMODEL\tSUBMITTED\tARN")
+	for _, j := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(j.JobName),
+			j.Status,
+			aws.ToString(j.ModelId),
+			formatTime(j.SubmitTime),
+			aws.ToString(j.JobArn))
+	}
+	w.Flush()
+}
+
+func status(args []string) {
+
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the job as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . status [-json] JOB_ARN")
+	}
+
+	job, err := getJob(fs.Arg(0))
+	if err != nil {
+		fmt.Println("failed to get model invocation job")
+		log.Fatal(err)
+	}
+
+	if *jsonOutput {
+		printJSON(job)
+		return
+	}
+
+	printJob(job)
+}
+
+func printJob(job *bedrock.GetModelInvocationJobOutput) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", aws.ToString(job.JobName))
+	fmt.Fprintf(w, "ARN:\t%s\n", aws.ToString(job.JobArn))
+	fmt.Fprintf(w, "Status:\t%s\n", job.Status)
+	fmt.Fprintf(w, "Model:\t%s\n", aws.ToString(job.ModelId))
+	fmt.Fprintf(w, "Input:\t%s\n", inputURI(job.InputDataConfig))
+	fmt.Fprintf(w, "Output:\t%s\n", outputURI(job.OutputDataConfig))
+	fmt.Fprintf(w, "Submitted:\t%s\n", formatTime(job.SubmitTime))
+	fmt.Fprintf(w, "Ended:\t%s\n", formatTime(job.EndTime))
+	fmt.Fprintf(w, "Expires:\t%s\n", formatTime(job.JobExpirationTime))
+
+	if job.Message != nil {
+		fmt.Fprintf(w, "Message:\t%s\n", *job.Message)
+	}
+	w.Flush()
+}
+
+func getJob(id string) (*bedrock.GetModelInvocationJobOutput, error) {
+	return bc.GetModelInvocationJob(context.Background(), &bedrock.GetModelInvocationJobInput{
+		JobIdentifier: aws.String(id),
+	})
+}
+
+func stop(args []string) {
+
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . stop JOB_ARN")
+	}
+
+	_, err := bc.StopModelInvocationJob(context.Background(), &bedrock.StopModelInvocationJobInput{
+		JobIdentifier: aws.String(fs.Arg(0)),
+	})
+
+	if err != nil {
+		fmt.Println("failed to stop model invocation job")
+		log.Fatal(err)
+	}
+
+	fmt.Println("stopping model invocation job", fs.Arg(0))
+}
+
+// wait polls the job until it has finished, and exits with a non-zero code
+// unless it completed.
+func wait(args []string) {
+
+	fs := flag.NewFlagSet("wait", flag.ExitOnError)
+	interval := fs.Duration("interval", time.Minute, "polling interval")
+	timeout := fs.Duration("timeout", 24*time.Hour, "maximum time to wait")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . wait [-interval 1m] [-timeout 24h] JOB_ARN")
+	}
+
+	deadline := time.Now().Add(*timeout)
+
+	for {
+		job, err := getJob(fs.Arg(0))
+		if err != nil {
+			fmt.Println("failed to get model invocation job")
+			log.Fatal(err)
+		}
+
+		switch job.Status {
+		case types.ModelInvocationJobStatusSubmitted,
+			types.ModelInvocationJobStatusValidating,
+			types.ModelInvocationJobStatusScheduled,
+			types.ModelInvocationJobStatusInProgress,
+			types.ModelInvocationJobStatusStopping:
+			log.Printf("job %s is %s", aws.ToString(job.JobName), job.Status)
+		default:
+			printJob(job)
+			if job.Status != types.ModelInvocationJobStatusCompleted {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if time.Now().After(deadline) {
+			log.Fatal("timed out waiting for job ", aws.ToString(job.JobName))
+		}
+
+		time.Sleep(*interval)
+	}
+}
+
+// results parses the job output and joins each model response to its prompt.
+// The output location and model are looked up from the job, or can be given
+// explicitly to parse output that has already been downloaded.
+func results(args []string) {
+
+	fs := flag.NewFlagSet("results", flag.ExitOnError)
+	jobID := fs.String("job", "", "ARN of the model invocation job")
+	promptsFile := fs.String("prompts", "", "the prompts file the job was submitted with")
+	location := fs.String("output", "", "local directory or s3://bucket/prefix with the job output (defaults to the job output location)")
+	modelID := fs.String("model", "", "ID of the model the job invoked (defaults to the job model)")
+	outFile := fs.String("out", "", "file to write the results to as JSONL (stdout if empty)")
+	fs.Parse(args)
+
+	if *promptsFile == "" {
+		log.Fatal("prompts file is required")
+	}
+
+	prefix := ""
+
+	if *jobID != "" {
+		job, err := getJob(*jobID)
+		if err != nil {
+			fmt.Println("failed to get model invocation job")
+			log.Fatal(err)
+		}
+
+		if *location == "" {
+			*location = outputURI(job.OutputDataConfig)
+			//output files are written under a folder named after the job ID
+			prefix = path.Base(aws.ToString(job.JobArn)) + "/"
+		}
+		if *modelID == "" {
+			*modelID = path.Base(aws.ToString(job.ModelId))
+		}
+	}
+
+	if *location == "" || *modelID == "" {
+		log.Fatal("either job, or output and model are required")
+	}
+
+	family, err := models.FamilyOf(*modelID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prompts, err := readPrompts(*promptsFile)
+	if err != nil {
+		log.Fatal("failed to read prompts: ", err)
+	}
+
+	storage, err := newStorage(*location)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys, err := storage.List(context.Background(), prefix)
+	if err != nil {
+		log.Fatal("failed to list batch output: ", err)
+	}
+
+	var all []BatchResult
+
+	for _, key := range keys {
+		//skip manifest.json.out and anything else that isn't record output
+		if !strings.HasSuffix(key, ".jsonl.out") {
+			continue
+		}
+
+		data, err := storage.Get(context.Background(), key)
+		if err != nil {
+			log.Fatal("failed to read batch output: ", err)
+		}
+
+		res, err := parseOutput(family, prompts, data)
+		if err != nil {
+			log.Fatalf("failed to parse %s: %v", storage.URI(key), err)
+		}
+		all = append(all, res...)
+	}
+
+	if len(all) == 0 {
+		log.Fatal("no batch output found in ", storage.URI(prefix))
+	}
+
+	out := os.Stdout
+	if *outFile != "" {
+		out, err = os.Create(*outFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+
+	enc := json.NewEncoder(out)
+	failed := 0
+	for _, r := range all {
+		if r.Error != nil {
+			failed++
+		}
+		err := enc.Encode(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Printf("%d results (%d failed) for %d prompts", len(all), failed, len(prompts))
+}
+
+func inputURI(c types.ModelInvocationJobInputDataConfig) string {
+	if s3Config, ok := c.(*types.ModelInvocationJobInputDataConfigMemberS3InputDataConfig); ok {
+		return aws.ToString(s3Config.Value.S3Uri)
+	}
+	return ""
+}
+
+func outputURI(c types.ModelInvocationJobOutputDataConfig) string {
+	if s3Config, ok := c.(*types.ModelInvocationJobOutputDataConfigMemberS3OutputDataConfig); ok {
+		return aws.ToString(s3Config.Value.S3Uri)
+	}
+	return ""
+}
+
+func printJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+)
+
+const claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+
+const defaultMaxTokens = 512
+
+// PromptRecord is a line of the local prompts file.
+type PromptRecord struct {
+	ID          string   `json:"id,omitempty"`
+	Prompt      string   `json:"prompt"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// BatchRecord is a line of the batch job input file.
+type BatchRecord struct {
+	RecordID   string      `json:"recordId"`
+	ModelInput interface{} `json:"modelInput"`
+}
+
+// BatchOutputRecord is a line of the batch job output file.
+type BatchOutputRecord struct {
+	RecordID    string          `json:"recordId"`
+	ModelInput  json.RawMessage `json:"modelInput"`
+	ModelOutput json.RawMessage `json:"modelOutput,omitempty"`
+	Error       *RecordError    `json:"error,omitempty"`
+}
+
+type RecordError struct {
+	ErrorCode    int    `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// BatchResult is a model response joined to the prompt it was generated for.
+// Response holds the typed response body of the model family.
+type BatchResult struct {
+	RecordID   string       `json:"record_id"`
+	Prompt     PromptRecord `json:"prompt"`
+	Completion string       `json:"completion,omitempty"`
+	Error      *RecordError `json:"error,omitempty"`
+	Response   interface{}  `json:"response,omitempty"`
+}
+
+// recordIDPattern is the format of batch record IDs - Bedrock expects 11
+// character alphanumeric IDs.
+var recordIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{11}$`)
+
+// recordID returns the ID of the prompt, or a generated one based on its
+// position in the file.
+func recordID(p PromptRecord, index int) string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return fmt.Sprintf("REC%08d", index)
+}
+
+func readPrompts(file string) ([]PromptRecord, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prompts []PromptRecord
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var p PromptRecord
+		err := json.Unmarshal(scanner.Bytes(), &p)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if p.Prompt == "" {
+			return nil, fmt.Errorf("line %d: missing prompt", line)
+		}
+
+		if p.ID != "" && !recordIDPattern.MatchString(p.ID) {
+			return nil, fmt.Errorf("line %d: invalid id %q - record IDs must be 11 alphanumeric characters", line, p.ID)
+		}
+
+		prompts = append(prompts, p)
+	}
+
+	return prompts, scanner.Err()
+}
+
+// modelInput encodes the prompt using the request body of the model family.
+func modelInput(family models.Family, p PromptRecord) interface{} {
+
+	maxTokens := p.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	switch family {
+	case models.CohereCommand:
+		return CohereRequest{Prompt: p.Prompt, MaxTokens: maxTokens, Temperature: p.Temperature}
+	case models.TitanText:
+		return TitanTextRequest{
+			InputText:            p.Prompt,
+			TextGenerationConfig: TextGenerationConfig{MaxTokenCount: maxTokens, Temperature: p.Temperature},
+		}
+	default:
+		return ClaudeRequest{Prompt: fmt.Sprintf(claudePromptFormat, p.Prompt), MaxTokensToSample: maxTokens, Temperature: p.Temperature}
+	}
+}
+
+// buildInput converts prompts into the JSONL batch input format.
+func buildInput(family models.Family, prompts []PromptRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	seen := map[string]bool{}
+	for i, p := range prompts {
+		id := recordID(p, i)
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate record ID %s", id)
+		}
+		seen[id] = true
+
+		err := enc.Encode(BatchRecord{RecordID: id, ModelInput: modelInput(family, p)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseOutput decodes batch output JSONL into typed responses and joins them
+// to the original prompts by record ID.
+func parseOutput(family models.Family, prompts []PromptRecord, output []byte) ([]BatchResult, error) {
+
+	byID := map[string]PromptRecord{}
+	for i, p := range prompts {
+		byID[recordID(p, i)] = p
+	}
+
+	var results []BatchResult
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var out BatchOutputRecord
+		err := json.Unmarshal(scanner.Bytes(), &out)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		prompt, ok := byID[out.RecordID]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown record ID %s", line, out.RecordID)
+		}
+
+		result := BatchResult{RecordID: out.RecordID, Prompt: prompt, Error: out.Error}
+
+		if out.Error == nil && len(out.ModelOutput) > 0 {
+			result.Response, result.Completion, err = decodeOutput(family, out.ModelOutput)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, scanner.Err()
+}
+
+func decodeOutput(family models.Family, data []byte) (interface{}, string, error) {
+	switch family {
+	case models.CohereCommand:
+		var resp CohereResponse
+		err := json.Unmarshal(data, &resp)
+		if err != nil || len(resp.Generations) == 0 {
+			return resp, "", err
+		}
+		return resp, resp.Generations[0].Text, nil
+	case models.TitanText:
+		var resp TitanTextResponse
+		err := json.Unmarshal(data, &resp)
+		if err != nil || len(resp.Results) == 0 {
+			return resp, "", err
+		}
+		return resp, resp.Results[0].OutputText, nil
+	default:
+		var resp ClaudeResponse
+		err := json.Unmarshal(data, &resp)
+		return resp, resp.Completion, err
+	}
+}
+
+//request/response model (Claude)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+//request/response model (Cohere)
+
+type CohereRequest struct {
+	Prompt        string   `json:"prompt"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	P             *float64 `json:"p,omitempty"`
+	K             *float64 `json:"k,omitempty"`
+	MaxTokens     int      `json:"max_tokens,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+type CohereGeneration struct {
+	ID           string `json:"id"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+type CohereResponse struct {
+	Generations []CohereGeneration `json:"generations"`
+	ID          string             `json:"id"`
+	Prompt      string             `json:"prompt"`
+}
+
+//request/response model (Titan Text)
+
+type TitanTextRequest struct {
+	InputText            string               `json:"inputText"`
+	TextGenerationConfig TextGenerationConfig `json:"textGenerationConfig"`
+}
+
+type TextGenerationConfig struct {
+	MaxTokenCount int      `json:"maxTokenCount,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type TitanTextResult struct {
+	TokenCount       int    `json:"tokenCount"`
+	OutputText       string `json:"outputText"`
+	CompletionReason string `json:"completionReason"`
+}
+
+type TitanTextResponse struct {
+	InputTextTokenCount int               `json:"inputTextTokenCount"`
+	Results             []TitanTextResult `json:"results"`
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+)
+
+// writePrompts stores the prompts file in a temporary local directory and
+// reads it back with readPrompts.
+func writePrompts(t *testing.T, lines ...string) ([]PromptRecord, error) {
+	t.Helper()
+
+	store := LocalStorage{Dir: t.TempDir()}
+	err := store.Put(context.Background(), "prompts.jsonl", []byte(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return readPrompts(store.URI("prompts.jsonl"))
+}
+
+func TestReadPrompts(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    int
+		wantErr string
+	}{
+		{"valid", []string{`{"id":"capitals001","prompt":"a"}`, ``, `{"prompt":"b"}`}, 2, ""},
+		{"missing prompt", []string{`{"id":"capitals001"}`}, 0, "missing prompt"},
+		{"short id", []string{`{"id":"abc","prompt":"a"}`}, 0, "invalid id"},
+		{"non alphanumeric id", []string{`{"id":"capitals-01","prompt":"a"}`}, 0, "invalid id"},
+		{"invalid JSON", []string{`{"prompt":`}, 0, "line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompts, err := writePrompts(t, tt.lines...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(prompts) != tt.want {
+				t.Fatalf("got %d prompts, want %d", len(prompts), tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  models.Family
+		prompts []PromptRecord
+		wantIDs []string
+		wantErr string
+	}{
+		{
+			name:    "generated IDs",
+			family:  models.Claude,
+			prompts: []PromptRecord{{Prompt: "a"}, {ID: "capitals001", Prompt: "b"}, {Prompt: "c"}},
+			wantIDs: []string{"REC00000000", "capitals001", "REC00000002"},
+		},
+		{
+			name:    "duplicate IDs",
+			family:  models.Claude,
+			prompts: []PromptRecord{{ID: "capitals001", Prompt: "a"}, {ID: "capitals001", Prompt: "b"}},
+			wantErr: "duplicate record ID capitals001",
+		},
+		{
+			name:    "duplicate of a generated ID",
+			family:  models.CohereCommand,
+			prompts: []PromptRecord{{Prompt: "a"}, {ID: "REC00000000", Prompt: "b"}},
+			wantErr: "duplicate record ID REC00000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := buildInput(tt.family, tt.prompts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			scanner := bufio.NewScanner(bytes.NewReader(input))
+			for scanner.Scan() {
+				var r struct {
+					RecordID string `json:"recordId"`
+				}
+				err := json.Unmarshal(scanner.Bytes(), &r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, r.RecordID)
+			}
+
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Fatalf("got IDs %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestBuildInputModelFamilies(t *testing.T) {
+	tests := []struct {
+		family models.Family
+		want   string
+	}{
+		{models.Claude, `{"recordId":"REC00000000","modelInput":{"prompt":"\n\nHuman:hi\n\nAssistant:","max_tokens_to_sample":512}}`},
+		{models.CohereCommand, `{"recordId":"REC00000000","modelInput":{"prompt":"hi","max_tokens":512}}`},
+		{models.TitanText, `{"recordId":"REC00000000","modelInput":{"inputText":"hi","textGenerationConfig":{"maxTokenCount":512}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.family), func(t *testing.T) {
+			input, err := buildInput(tt.family, []PromptRecord{{Prompt: "hi"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(input)); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	prompts := []PromptRecord{{ID: "capitals001", Prompt: "a"}, {Prompt: "b"}}
+
+	tests := []struct {
+		name           string
+		family         models.Family
+		output         []string
+		wantCompletion []string
+		wantErrors     []int
+		wantErr        string
+	}{
+		{
+			name:   "claude",
+			family: models.Claude,
+			output: []string{
+				`{"recordId":"capitals001","modelInput":{},"modelOutput":{"completion":" Paris","stop_reason":"stop_sequence"}}`,
+				`{"recordId":"REC00000001","modelInput":{},"modelOutput":{"completion":" waves"}}`,
+			},
+			wantCompletion: []string{" Paris", " waves"},
+			wantErrors:     []int{0, 0},
+		},
+		{
+			name:   "cohere command",
+			family: models.CohereCommand,
+			output: []string{
+				`{"recordId":"capitals001","modelInput":{},"modelOutput":{"generations":[{"id":"g","text":"Paris"}],"id":"r"}}`,
+				`{"recordId":"REC00000001","modelInput":{},"modelOutput":{"generations":[]}}`,
+			},
+			wantCompletion: []string{"Paris", ""},
+			wantErrors:     []int{0, 0},
+		},
+		{
+			name:   "titan text",
+			family: models.TitanText,
+			output: []string{
+				`{"recordId":"capitals001","modelInput":{},"modelOutput":{"inputTextTokenCount":3,"results":[{"tokenCount":1,"outputText":"Paris","completionReason":"FINISH"}]}}`,
+			},
+			wantCompletion: []string{"Paris"},
+			wantErrors:     []int{0},
+		},
+		{
+			name:   "error records",
+			family: models.Claude,
+			output: []string{
+				`{"recordId":"capitals001","modelInput":{},"error":{"errorCode":400,"errorMessage":"Malformed input request"}}`,
+				``,
+				`{"recordId":"REC00000001","modelInput":{},"modelOutput":{"completion":"ok"}}`,
+			},
+			wantCompletion: []string{"", "ok"},
+			wantErrors:     []int{400, 0},
+		},
+		{
+			name:    "unknown record ID",
+			family:  models.Claude,
+			output:  []string{`{"recordId":"unknown0001","modelInput":{},"modelOutput":{"completion":"x"}}`},
+			wantErr: "line 1: unknown record ID unknown0001",
+		},
+		{
+			name:    "invalid model output",
+			family:  models.TitanText,
+			output:  []string{`{"recordId":"capitals001","modelInput":{},"modelOutput":{"results":"x"}}`},
+			wantErr: "line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := LocalStorage{Dir: t.TempDir()}
+
+			err := store.Put(ctx, "job/prompts.jsonl.out", []byte(strings.Join(tt.output, "\n")))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output, err := store.Get(ctx, "job/prompts.jsonl.out")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := parseOutput(tt.family, prompts, output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != len(tt.wantCompletion) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantCompletion))
+			}
+
+			for i, r := range results {
+				if r.Completion != tt.wantCompletion[i] {
+					t.Errorf("result %d: got completion %q, want %q", i, r.Completion, tt.wantCompletion[i])
+				}
+
+				var code int
+				if r.Error != nil {
+					code = r.Error.ErrorCode
+				}
+				if code != tt.wantErrors[i] {
+					t.Errorf("result %d: got error code %d, want %d", i, code, tt.wantErrors[i])
+				}
+
+				if r.Prompt.Prompt != prompts[i].Prompt {
+					t.Errorf("result %d: joined to prompt %q, want %q", i, r.Prompt.Prompt, prompts[i].Prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	tests := []struct {
+		family models.Family
+		data   string
+		want   string
+	}{
+		{models.Claude, `{"completion":" Paris","stop_reason":"stop_sequence"}`, " Paris"},
+		{models.CohereCommand, `{"generations":[{"text":"Paris"},{"text":"Lyon"}]}`, "Paris"},
+		{models.TitanText, `{"results":[{"outputText":"Paris"}]}`, "Paris"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.family), func(t *testing.T) {
+			resp, completion, err := decodeOutput(tt.family, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if completion != tt.want {
+				t.Fatalf("got %q, want %q", completion, tt.want)
+			}
+			if resp == nil {
+				t.Fatal("got no typed response")
+			}
+		})
+	}
+}
+
+func TestBuildInputZeroTemperature(t *testing.T) {
+	var prompts []PromptRecord
+	err := json.Unmarshal([]byte(`[{"prompt":"hi","temperature":0}]`), &prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := buildInput(models.Claude, prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(input), `"temperature":0`) {
+		t.Fatalf("explicit temperature of 0 was dropped: %s", input)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Storage is where batch input and output files are kept. Bedrock batch jobs
+// read from and write to S3, but a local directory can be used to prepare
+// input files and parse downloaded output without AWS access.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys under prefix, relative to the storage root.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// URI returns the location of key, e.g. s3://bucket/prefix/key.
+	URI(key string) string
+}
+
+// newStorage returns S3 storage for s3:// locations and local storage for
+// anything else.
+func newStorage(location string) (Storage, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return LocalStorage{Dir: location}, nil
+	}
+
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 location %s", location)
+	}
+
+	return S3Storage{Client: s3c, Bucket: bucket, Prefix: strings.TrimSuffix(prefix, "/")}, nil
+}
+
+type LocalStorage struct {
+	Dir string
+}
+
+func (s LocalStorage) Put(ctx context.Context, key string, data []byte) error {
+	file := filepath.Join(s.Dir, filepath.FromSlash(key))
+
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+func (s LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(key)))
+}
+
+func (s LocalStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	err := filepath.WalkDir(s.Dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.Dir, p)
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+
+	sort.Strings(keys)
+	return keys, err
+}
+
+func (s LocalStorage) URI(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+type S3Storage struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+func (s S3Storage) key(key string) string {
+	return strings.TrimPrefix(path.Join(s.Prefix, key), "/")
+}
+
+func (s S3Storage) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return io.ReadAll(out.Body)
+}
+
+func (s S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	root := s.key("")
+	if root != "" {
+		root += "/"
+	}
+
+	p := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(root + prefix),
+	})
+
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(aws.ToString(obj.Key), root))
+		}
+	}
+
+	return keys, nil
+}
+
+func (s S3Storage) URI(key string) string {
+	return "s3://" + s.Bucket + "/" + s.key(key)
+}
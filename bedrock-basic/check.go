@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/smithy-go"
+)
+
+// availability of a model in a region
+const (
+	Available  = "available"
+	Listed     = "listed" //offered in the region, but not probed
+	NotEnabled = "not-enabled"
+	NotOffered = "not-offered"
+	Failed     = "error"
+)
+
+// exit codes of the check command
+const (
+	exitAvailable  = 0
+	exitFailed     = 1
+	exitNotEnabled = 2
+	exitNotOffered = 3
+	exitUnverified = 4
+)
+
+// exitCodes lists the exit code of each status from the highest to the
+// lowest priority. Errors come first, so that an API or credentials problem
+// is never hidden behind a model that is simply not enabled.
+var exitCodes = []struct {
+	status string
+	code   int
+}{
+	{Failed, exitFailed},
+	{NotOffered, exitNotOffered},
+	{NotEnabled, exitNotEnabled},
+	{Listed, exitUnverified},
+}
+
+// CheckResult is the availability of a model in a region.
+type CheckResult struct {
+	ModelID string `json:"model_id"`
+	Region  string `json:"region"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// check reports, for every model and region, whether the model is offered in
+// the region and whether the account has access to it. It exits with 0 only
+// when every model was verified to be available everywhere. Otherwise it
+// exits with 1 on any error, else 3 when a model is not offered, else 2 when
+// a model is not enabled, else 4 when a model is offered but could not be
+// probed.
+func check(args []string) {
+
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	models := fs.String("models", "", "comma separated list of model IDs to check")
+	regions := fs.String("regions", defaultRegion, "comma separated list of regions to check")
+	probe := fs.Bool("probe", true, "perform a minimal invocation to verify model access")
+	jsonOutput := fs.Bool("json", false, "print the results as JSON")
+	fs.Parse(args)
+
+	modelIDs := splitList(*models)
+	regionList := splitList(*regions)
+
+	if len(modelIDs) == 0 || len(regionList) == 0 {
+		log.Fatal("usage: go run . check -models MODEL_ID[,MODEL_ID] [-regions REGION[,REGION]]")
+	}
+
+	var results []CheckResult
+	for _, region := range regionList {
+		results = append(results, checkRegion(context.Background(), region, modelIDs, *probe)...)
+	}
+
+	if *jsonOutput {
+		printJSON(results)
+	} else {
+		printMatrix(results, modelIDs, regionList)
+	}
+
+	os.Exit(exitCode(results))
+}
+
+func checkRegion(ctx context.Context, region string, modelIDs []string, probe bool) []CheckResult {
+
+	results := make([]CheckResult, 0, len(modelIDs))
+	fail := func(err error) []CheckResult {
+		for _, id := range modelIDs {
+			results = append(results, CheckResult{ModelID: id, Region: region, Status: Failed, Detail: err.Error()})
+		}
+		return results
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return fail(err)
+	}
+
+	fms, err := bedrock.NewFromConfig(cfg).ListFoundationModels(ctx, &bedrock.ListFoundationModelsInput{})
+	if err != nil {
+		return fail(err)
+	}
+
+	offered := map[string]bool{}
+	for _, fm := range fms.ModelSummaries {
+		offered[aws.ToString(fm.ModelId)] = true
+	}
+
+	brc := bedrockruntime.NewFromConfig(cfg)
+
+	for _, id := range modelIDs {
+		result := CheckResult{ModelID: id, Region: region}
+
+		switch {
+		case !offered[id]:
+			result.Status = NotOffered
+		case !probe:
+			result.Status = Listed
+		default:
+			result.Status, result.Detail = probeModel(ctx, brc, id)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// probeModel invokes the model with the smallest possible request for its
+// family and classifies the outcome.
+func probeModel(ctx context.Context, brc *bedrockruntime.Client, modelID string) (string, string) {
+
+	body, ok := probeBody(modelID)
+	if !ok {
+		return Listed, "no probe request for this model family"
+	}
+
+	_, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		Body:        body,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("*/*"),
+	})
+
+	if err == nil {
+		return Available, ""
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDeniedException":
+			return NotEnabled, apiErr.ErrorMessage()
+		case "ResourceNotFoundException":
+			return NotOffered, apiErr.ErrorMessage()
+		}
+	}
+
+	return Failed, err.Error()
+}
+
+// probeBody returns a minimal request body for the model family. Image models
+// are not probed since every invocation generates (and bills for) an image.
+func probeBody(modelID string) ([]byte, bool) {
+
+	var body interface{}
+
+	switch {
+	case strings.HasPrefix(modelID, "anthropic.claude-3"):
+		body = map[string]interface{}{
+			"anthropic_version": "bedrock-2023-05-31",
+			"max_tokens":        1,
+			"messages":          []map[string]string{{"role": "user", "content": "hi"}},
+		}
+	case strings.HasPrefix(modelID, "anthropic."):
+		body = map[string]interface{}{"prompt": "\n\nHuman: hi\n\nAssistant:", "max_tokens_to_sample": 1}
+	case strings.HasPrefix(modelID, "cohere.embed"):
+		body = map[string]interface{}{"texts": []string{"hi"}, "input_type": "search_document"}
+	case strings.HasPrefix(modelID, "cohere."):
+		body = map[string]interface{}{"prompt": "hi", "max_tokens": 1}
+	case strings.HasPrefix(modelID, "amazon.titan-embed"):
+		body = map[string]interface{}{"inputText": "hi"}
+	case strings.HasPrefix(modelID, "amazon.titan-text"):
+		body = map[string]interface{}{"inputText": "hi", "textGenerationConfig": map[string]int{"maxTokenCount": 1}}
+	case strings.HasPrefix(modelID, "ai21."):
+		body = map[string]interface{}{"prompt": "hi", "maxTokens": 1}
+	case strings.HasPrefix(modelID, "meta."):
+		body = map[string]interface{}{"prompt": "hi", "max_gen_len": 1}
+	default:
+		return nil, false
+	}
+
+	b, err := json.Marshal(body)
+	return b, err == nil
+}
+
+func exitCode(results []CheckResult) int {
+	found := map[string]bool{}
+	for _, r := range results {
+		found[r.Status] = true
+	}
+
+	for _, e := range exitCodes {
+		if found[e.status] {
+			return e.code
+		}
+	}
+	return exitAvailable
+}
+
+func printMatrix(results []CheckResult, modelIDs, regions []string) {
+
+	status := map[string]string{}
+	for _, r := range results {
+		status[r.ModelID+"|"+r.Region] = r.Status
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "MODEL\t%s\n", strings.Join(regions, "\t"))
+	for _, id := range modelIDs {
+		row := []string{id}
+		for _, region := range regions {
+			row = append(row, status[id+"|"+region])
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	for _, r := range results {
+		if r.Detail != "" && r.Status != Available {
+			fmt.Printf("\n%s (%s): %s", r.ModelID, r.Region, r.Detail)
+		}
+	}
+	fmt.Println()
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+)
+
+// default limits for training data, based on the Bedrock fine-tuning quotas
+const (
+	defaultMaxRecords      = 10000
+	defaultMaxRecordLength = 16000 //characters, roughly 4096 tokens
+	defaultMaxFileSize     = 1 << 30
+
+	maxReportedErrors = 20
+)
+
+// jobs manages model customization (fine-tuning) jobs.
+func jobs(args []string) {
+
+	usage := "usage: go run . jobs create|list|describe|stop|wait [flags]"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	switch args[0] {
+	case "create":
+		createJob(args[1:])
+	case "list":
+		listJobs(args[1:])
+	case "describe":
+		describeJob(args[1:])
+	case "stop":
+		stopJob(args[1:])
+	case "wait":
+		waitJob(args[1:])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func createJob(args []string) {
+
+	var hyperParameters keyValueFlag
+
+	fs := flag.NewFlagSet("jobs create", flag.ExitOnError)
+	name := fs.String("name", "", "job name")
+	baseModel := fs.String("base-model", "", "ID or ARN of the model to customize (e.g. amazon.titan-text-express-v1)")
+	customModel := fs.String("custom-model-name", "", "name of the resulting custom model")
+	roleArn := fs.String("role-arn", "", "ARN of the IAM role Bedrock assumes to access the training data")
+	trainingData := fs.String("training-data", "", "S3 URI of the training data (JSONL)")
+	validationData := fs.String("validation-data", "", "S3 URI of the validation data (JSONL)")
+	output := fs.String("output", "", "S3 URI for the job output")
+	customizationType := fs.String("customization-type", string(types.CustomizationTypeFineTuning), "FINE_TUNING or CONTINUED_PRE_TRAINING")
+	validateFile := fs.String("validate-file", "", "local copy of the training data to validate before creating the job")
+	fs.Var(&hyperParameters, "hyper-parameter", "hyper parameter as name=value, e.g. epochCount=2 (repeatable)")
+	fs.Parse(args)
+
+	if *name == "" || *baseModel == "" || *customModel == "" || *roleArn == "" || *trainingData == "" || *output == "" {
+		log.Fatal("name, base-model, custom-model-name, role-arn, training-data and output are required")
+	}
+
+	if *validateFile != "" {
+		report, err := validateTrainingData(*validateFile, types.CustomizationType(*customizationType), defaultTrainingLimits())
+		if err != nil {
+			log.Fatal("failed to validate training data: ", err)
+		}
+
+		if !report.print() {
+			log.Fatal("training data is invalid - not creating the job")
+		}
+	}
+
+	input := &bedrock.CreateModelCustomizationJobInput{
+		JobName:             aws.String(*name),
+		BaseModelIdentifier: aws.String(*baseModel),
+		CustomModelName:     aws.String(*customModel),
+		RoleArn:             aws.String(*roleArn),
+		CustomizationType:   types.CustomizationType(*customizationType),
+		HyperParameters:     hyperParameters,
+		TrainingDataConfig:  &types.TrainingDataConfig{S3Uri: aws.String(*trainingData)},
+		OutputDataConfig:    &types.OutputDataConfig{S3Uri: aws.String(*output)},
+	}
+
+	if *validationData != "" {
+		input.ValidationDataConfig = &types.ValidationDataConfig{
+			Validators: []types.Validator{{S3Uri: aws.String(*validationData)}},
+		}
+	}
+
+	job, err := bc.CreateModelCustomizationJob(context.Background(), input)
+	if err != nil {
+		fmt.Println("failed to create model customization job")
+		log.Fatal(err)
+	}
+
+	fmt.Println("created model customization job", aws.ToString(job.JobArn))
+}
+
+func listJobs(args []string) {
+
+	fs := flag.NewFlagSet("jobs list", flag.ExitOnError)
+	status := fs.String("status", "", "only list jobs with this status - InProgress, Completed, Failed, Stopping or Stopped")
+	nameContains := fs.String("name-contains", "", "only list jobs whose name contains this string")
+	jsonOutput := fs.Bool("json", false, "print the jobs as JSON")
+	fs.Parse(args)
+
+	input := &bedrock.ListModelCustomizationJobsInput{
+		StatusEquals: types.FineTuningJobStatus(*status),
+		SortBy:       types.SortJobsByCreationTime,
+		SortOrder:    types.SortOrderDescending,
+	}
+
+	if *nameContains != "" {
+		input.NameContains = aws.String(*nameContains)
+	}
+
+	var summaries []types.ModelCustomizationJobSummary
+
+	p := bedrock.NewListModelCustomizationJobsPaginator(bc, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.Background())
+		if err != nil {
+			fmt.Println("failed to list model customization jobs")
+			log.Fatal(err)
+		}
+		summaries = append(summaries, page.ModelCustomizationJobSummaries...)
+	}
+
+	if *jsonOutput {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tTYPE\tCUSTOM MODEL\tCREATED\tARN")
+	for _, j := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(j.JobName),
+			j.Status,
+			j.CustomizationType,
+			aws.ToString(j.CustomModelName),
+			formatTime(j.CreationTime),
+			aws.ToString(j.JobArn))
+	}
+	w.Flush()
+}
+
+func describeJob(args []string) {
+
+	fs := flag.NewFlagSet("jobs describe", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the job as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . jobs describe [-json] JOB_NAME_OR_ARN")
+	}
+
+	job, err := getJob(fs.Arg(0))
+	if err != nil {
+		fmt.Println("failed to get model customization job")
+		log.Fatal(err)
+	}
+
+	if *jsonOutput {
+		printJSON(job)
+		return
+	}
+
+	printJob(job)
+}
+
+func printJob(job *bedrock.GetModelCustomizationJobOutput) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", aws.ToString(job.JobName))
+	fmt.Fprintf(w, "ARN:\t%s\n", aws.ToString(job.JobArn))
+	fmt.Fprintf(w, "Status:\t%s\n", job.Status)
+	fmt.Fprintf(w, "Type:\t%s\n", job.CustomizationType)
+	fmt.Fprintf(w, "Base model:\t%s\n", aws.ToString(job.BaseModelArn))
+	fmt.Fprintf(w, "Custom model:\t%s\n", aws.ToString(job.OutputModelName))
+	fmt.Fprintf(w, "Custom model ARN:\t%s\n", aws.ToString(job.OutputModelArn))
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(job.CreationTime))
+	fmt.Fprintf(w, "Ended:\t%s\n", formatTime(job.EndTime))
+
+	for k, v := range job.HyperParameters {
+		fmt.Fprintf(w, "Hyper parameter %s:\t%s\n", k, v)
+	}
+
+	if job.TrainingMetrics != nil && job.TrainingMetrics.TrainingLoss != nil {
+		fmt.Fprintf(w, "Training loss:\t%f\n", *job.TrainingMetrics.TrainingLoss)
+	}
+
+	for i, m := range job.ValidationMetrics {
+		if m.ValidationLoss != nil {
+			fmt.Fprintf(w, "Validation loss (%d):\t%f\n", i, *m.ValidationLoss)
+		}
+	}
+
+	if job.FailureMessage != nil {
+		fmt.Fprintf(w, "Failure:\t%s\n", *job.FailureMessage)
+	}
+	w.Flush()
+}
+
+func getJob(id string) (*bedrock.GetModelCustomizationJobOutput, error) {
+	return bc.GetModelCustomizationJob(context.Background(), &bedrock.GetModelCustomizationJobInput{
+		JobIdentifier: aws.String(id),
+	})
+}
+
+func stopJob(args []string) {
+
+	fs := flag.NewFlagSet("jobs stop", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . jobs stop JOB_NAME_OR_ARN")
+	}
+
+	_, err := bc.StopModelCustomizationJob(context.Background(), &bedrock.StopModelCustomizationJobInput{
+		JobIdentifier: aws.String(fs.Arg(0)),
+	})
+
+	if err != nil {
+		fmt.Println("failed to stop model customization job")
+		log.Fatal(err)
+	}
+
+	fmt.Println("stopping model customization job", fs.Arg(0))
+}
+
+// waitJob polls the job until it is no longer in progress, and exits with a
+// non-zero code unless it completed.
+func waitJob(args []string) {
+
+	fs := flag.NewFlagSet("jobs wait", flag.ExitOnError)
+	interval := fs.Duration("interval", time.Minute, "polling interval")
+	timeout := fs.Duration("timeout", 24*time.Hour, "maximum time to wait")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . jobs wait [-interval 1m] [-timeout 24h] JOB_NAME_OR_ARN")
+	}
+
+	deadline := time.Now().Add(*timeout)
+
+	for {
+		job, err := getJob(fs.Arg(0))
+		if err != nil {
+			fmt.Println("failed to get model customization job")
+			log.Fatal(err)
+		}
+
+		switch job.Status {
+		case types.ModelCustomizationJobStatusInProgress, types.ModelCustomizationJobStatusStopping:
+			log.Printf("job %s is %s", aws.ToString(job.JobName), job.Status)
+		default:
+			printJob(job)
+			if job.Status != types.ModelCustomizationJobStatusCompleted {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if time.Now().After(deadline) {
+			log.Fatal("timed out waiting for job ", aws.ToString(job.JobName))
+		}
+
+		time.Sleep(*interval)
+	}
+}
+
+// customModels lists the custom models in the account.
+func customModels(args []string) {
+
+	fs := flag.NewFlagSet("custom-models", flag.ExitOnError)
+	baseModel := fs.String("base-model", "", "only list custom models derived from this base model ARN")
+	jsonOutput := fs.Bool("json", false, "print the custom models as JSON")
+	fs.Parse(args)
+
+	input := &bedrock.ListCustomModelsInput{}
+	if *baseModel != "" {
+		input.BaseModelArnEquals = aws.String(*baseModel)
+	}
+
+	var summaries []types.CustomModelSummary
+
+	p := bedrock.NewListCustomModelsPaginator(bc, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.Background())
+		if err != nil {
+			fmt.Println("failed to list custom models")
+			log.Fatal(err)
+		}
+		summaries = append(summaries, page.ModelSummaries...)
+	}
+
+	if *jsonOutput {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBASE MODEL\tTYPE\tCREATED\tARN")
+	for _, m := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(m.ModelName),
+			aws.ToString(m.BaseModelName),
+			m.CustomizationType,
+			formatTime(m.CreationTime),
+			aws.ToString(m.ModelArn))
+	}
+	w.Flush()
+}
+
+// validate checks a local training data file before it is uploaded.
+func validate(args []string) {
+
+	limits := defaultTrainingLimits()
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	customizationType := fs.String("customization-type", string(types.CustomizationTypeFineTuning), "FINE_TUNING (prompt and completion records) or CONTINUED_PRE_TRAINING (input records)")
+	fs.IntVar(&limits.MaxRecords, "max-records", limits.MaxRecords, "maximum number of records")
+	fs.IntVar(&limits.MaxRecordLength, "max-record-length", limits.MaxRecordLength, "maximum length of a record (prompt plus completion, or input), in characters")
+	fs.Int64Var(&limits.MaxFileSize, "max-size", limits.MaxFileSize, "maximum file size in bytes")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . validate [flags] FILE.jsonl")
+	}
+
+	report, err := validateTrainingData(fs.Arg(0), types.CustomizationType(*customizationType), limits)
+	if err != nil {
+		log.Fatal("failed to validate training data: ", err)
+	}
+
+	if !report.print() {
+		os.Exit(1)
+	}
+}
+
+type TrainingLimits struct {
+	MaxRecords      int
+	MaxRecordLength int
+	MaxFileSize     int64
+}
+
+func defaultTrainingLimits() TrainingLimits {
+	return TrainingLimits{
+		MaxRecords:      defaultMaxRecords,
+		MaxRecordLength: defaultMaxRecordLength,
+		MaxFileSize:     defaultMaxFileSize,
+	}
+}
+
+// TrainingRecord is a single training example - prompt and completion for
+// fine-tuning, or input for continued pre-training.
+type TrainingRecord struct {
+	Prompt     *string `json:"prompt"`
+	Completion *string `json:"completion"`
+	Input      *string `json:"input"`
+}
+
+// trainingFields are the fields of a training record, by customization type.
+var trainingFields = map[types.CustomizationType][]string{
+	types.CustomizationTypeFineTuning:           {"prompt", "completion"},
+	types.CustomizationTypeContinuedPreTraining: {"input"},
+}
+
+type ValidationReport struct {
+	File    string
+	Records int
+	Errors  []string
+}
+
+// print writes the report and returns true if the data is valid.
+func (r ValidationReport) print() bool {
+	fmt.Printf("%s: %d records\n", r.File, r.Records)
+
+	for i, e := range r.Errors {
+		if i == maxReportedErrors {
+			fmt.Printf("  ... and %d more errors\n", len(r.Errors)-maxReportedErrors)
+			break
+		}
+		fmt.Println("  -", e)
+	}
+
+	if len(r.Errors) == 0 {
+		fmt.Println("training data is valid")
+		return true
+	}
+	return false
+}
+
+// validateTrainingData checks that every line is a JSON object with the
+// string fields of the customization type - prompt and completion for
+// fine-tuning, input for continued pre-training - and that the file is within
+// the limits.
+func validateTrainingData(file string, customizationType types.CustomizationType, limits TrainingLimits) (ValidationReport, error) {
+
+	report := ValidationReport{File: file}
+
+	fieldNames, ok := trainingFields[customizationType]
+	if !ok {
+		return report, fmt.Errorf("unsupported customization type %q", customizationType)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return report, err
+	}
+
+	if info.Size() > limits.MaxFileSize {
+		report.Errors = append(report.Errors, fmt.Sprintf("file is %d bytes, the maximum is %d", info.Size(), limits.MaxFileSize))
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return report, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		if len(strings.TrimSpace(string(text))) == 0 {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: empty line", line))
+			continue
+		}
+
+		report.Records++
+
+		if !utf8.Valid(text) {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: not valid UTF-8", line))
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+		err := json.Unmarshal(text, &fields)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: not a JSON object: %v", line, err))
+			continue
+		}
+
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			if !contains(fieldNames, k) {
+				report.Errors = append(report.Errors, fmt.Sprintf("line %d: unexpected field %q", line, k))
+			}
+		}
+
+		var record TrainingRecord
+		err = json.Unmarshal(text, &record)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: %s must be strings", line, strings.Join(fieldNames, " and ")))
+			continue
+		}
+
+		if customizationType == types.CustomizationTypeContinuedPreTraining {
+			if record.Input == nil || strings.TrimSpace(*record.Input) == "" {
+				report.Errors = append(report.Errors, fmt.Sprintf("line %d: missing or empty input", line))
+			}
+		} else {
+			if record.Prompt == nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("line %d: missing prompt", line))
+			}
+
+			if record.Completion == nil || strings.TrimSpace(*record.Completion) == "" {
+				report.Errors = append(report.Errors, fmt.Sprintf("line %d: missing or empty completion", line))
+			}
+		}
+
+		length := utf8.RuneCountInString(aws.ToString(record.Prompt)) + utf8.RuneCountInString(aws.ToString(record.Completion)) + utf8.RuneCountInString(aws.ToString(record.Input))
+		if length > limits.MaxRecordLength {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: record is %d characters, the maximum is %d", line, length, limits.MaxRecordLength))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	if report.Records == 0 {
+		report.Errors = append(report.Errors, "file has no records")
+	}
+
+	if report.Records > limits.MaxRecords {
+		report.Errors = append(report.Errors, fmt.Sprintf("file has %d records, the maximum is %d", report.Records, limits.MaxRecords))
+	}
+
+	return report, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// keyValueFlag collects repeated name=value flags into a map.
+type keyValueFlag map[string]string
+
+func (f *keyValueFlag) String() string {
+	var parts []string
+	for k, v := range *f {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+
+	if *f == nil {
+		*f = keyValueFlag{}
+	}
+	(*f)[k] = v
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
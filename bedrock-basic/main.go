@@ -2,17 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
 )
 
 const defaultRegion = "us-east-1"
 
-func main() {
+var bc *bedrock.Client
+
+func init() {
 
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -24,20 +32,174 @@ func main() {
 		log.Fatal(err)
 	}
 
-	bc := bedrock.NewFromConfig(cfg)
+	bc = bedrock.NewFromConfig(cfg)
+}
 
-	fms, err := bc.ListFoundationModels(context.Background(), &bedrock.ListFoundationModelsInput{
-		//ByProvider: aws.String("Amazon"),
-		//ByOutputModality: types.ModelModalityText,
-	})
+func main() {
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "describe":
+			describe(os.Args[2:])
+			return
+		case "check":
+			check(os.Args[2:])
+			return
+		case "jobs":
+			jobs(os.Args[2:])
+			return
+		case "custom-models":
+			customModels(os.Args[2:])
+			return
+		case "validate":
+			validate(os.Args[2:])
+			return
+		case "throughput":
+			throughput(os.Args[2:])
+			return
+		}
+	}
+
+	provider := flag.String("provider", "", "only list models from this provider (e.g. Amazon, Anthropic)")
+	inputModality := flag.String("input-modality", "", "only list models accepting this input modality - TEXT, IMAGE or EMBEDDING")
+	outputModality := flag.String("output-modality", "", "only list models with this output modality - TEXT, IMAGE or EMBEDDING")
+	customizationType := flag.String("customization-type", "", "only list models supporting this customization - FINE_TUNING or CONTINUED_PRE_TRAINING")
+	inferenceType := flag.String("inference-type", "", "only list models supporting this inference type - ON_DEMAND or PROVISIONED")
+	jsonOutput := flag.Bool("json", false, "print the models as JSON")
+	tableOutput := flag.Bool("table", false, "print the models as a table")
+	flag.Parse()
+
+	input := &bedrock.ListFoundationModelsInput{
+		ByOutputModality:    types.ModelModality(strings.ToUpper(*outputModality)),
+		ByCustomizationType: types.ModelCustomization(strings.ToUpper(*customizationType)),
+		ByInferenceType:     types.InferenceType(strings.ToUpper(*inferenceType)),
+	}
+
+	if *provider != "" {
+		input.ByProvider = aws.String(*provider)
+	}
+
+	fms, err := bc.ListFoundationModels(context.Background(), input)
 
 	if err != nil {
 		fmt.Println("failed to list foundation models")
 		log.Fatal(err)
 	}
 
-	for _, fm := range fms.ModelSummaries {
-		fmt.Println(fmt.Sprintf("Name: %s | Provider: %s | Id: %s | Modality: %s", *fm.ModelName, *fm.ProviderName, *fm.ModelId, fm.OutputModalities))
+	//there is no server side filter for the input modality
+	models := fms.ModelSummaries
+	if *inputModality != "" {
+		models = filterByInputModality(models, types.ModelModality(strings.ToUpper(*inputModality)))
 	}
 
+	switch {
+	case *jsonOutput:
+		printJSON(models)
+	case *tableOutput:
+		printTable(models)
+	default:
+		for _, fm := range models {
+			fmt.Println(fmt.Sprintf("Name: %s | Provider: %s | Id: %s | Modality: %s", *fm.ModelName, *fm.ProviderName, *fm.ModelId, fm.OutputModalities))
+		}
+	}
+
+}
+
+func filterByInputModality(models []types.FoundationModelSummary, modality types.ModelModality) []types.FoundationModelSummary {
+	var filtered []types.FoundationModelSummary
+	for _, fm := range models {
+		for _, m := range fm.InputModalities {
+			if m == modality {
+				filtered = append(filtered, fm)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func printJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+}
+
+func printTable(models []types.FoundationModelSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPROVIDER\tSTATUS\tINPUT\tOUTPUT\tSTREAMING\tINFERENCE\tCUSTOMIZATIONS")
+	for _, fm := range models {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			aws.ToString(fm.ModelId),
+			aws.ToString(fm.ModelName),
+			aws.ToString(fm.ProviderName),
+			lifecycleStatus(fm.ModelLifecycle),
+			join(fm.InputModalities),
+			join(fm.OutputModalities),
+			aws.ToBool(fm.ResponseStreamingSupported),
+			join(fm.InferenceTypesSupported),
+			join(fm.CustomizationsSupported))
+	}
+	w.Flush()
+}
+
+// describe prints the details of a single foundation model.
+func describe(args []string) {
+
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the model details as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . describe [-json] MODEL_ID")
+	}
+
+	fm, err := bc.GetFoundationModel(context.Background(), &bedrock.GetFoundationModelInput{
+		ModelIdentifier: aws.String(fs.Arg(0)),
+	})
+
+	if err != nil {
+		fmt.Println("failed to get foundation model")
+		log.Fatal(err)
+	}
+
+	d := fm.ModelDetails
+
+	if *jsonOutput {
+		printJSON(d)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Id:\t%s\n", aws.ToString(d.ModelId))
+	fmt.Fprintf(w, "ARN:\t%s\n", aws.ToString(d.ModelArn))
+	fmt.Fprintf(w, "Name:\t%s\n", aws.ToString(d.ModelName))
+	fmt.Fprintf(w, "Provider:\t%s\n", aws.ToString(d.ProviderName))
+	fmt.Fprintf(w, "Lifecycle status:\t%s\n", lifecycleStatus(d.ModelLifecycle))
+	fmt.Fprintf(w, "Streaming supported:\t%t\n", aws.ToBool(d.ResponseStreamingSupported))
+	fmt.Fprintf(w, "Input modalities:\t%s\n", join(d.InputModalities))
+	fmt.Fprintf(w, "Output modalities:\t%s\n", join(d.OutputModalities))
+	fmt.Fprintf(w, "Inference types:\t%s\n", join(d.InferenceTypesSupported))
+	fmt.Fprintf(w, "Customizations:\t%s\n", join(d.CustomizationsSupported))
+	w.Flush()
+}
+
+func lifecycleStatus(l *types.FoundationModelLifecycle) types.FoundationModelLifecycleStatus {
+	if l == nil {
+		return "-"
+	}
+	return l.Status
+}
+
+func join[T ~string](values []T) string {
+	if len(values) == 0 {
+		return "-"
+	}
+
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, string(v))
+	}
+	return strings.Join(s, ",")
 }
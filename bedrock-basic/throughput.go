@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+)
+
+// throughput manages provisioned model throughput.
+func throughput(args []string) {
+
+	usage := "usage: go run . throughput create|list|describe|update|delete [flags]"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	switch args[0] {
+	case "create":
+		createThroughput(args[1:])
+	case "list":
+		listThroughputs(args[1:])
+	case "describe":
+		describeThroughput(args[1:])
+	case "update":
+		updateThroughput(args[1:])
+	case "delete":
+		deleteThroughput(args[1:])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func createThroughput(args []string) {
+
+	fs := flag.NewFlagSet("throughput create", flag.ExitOnError)
+	name := fs.String("name", "", "name of the provisioned model")
+	modelID := fs.String("model", "", "ID or ARN of the base or custom model")
+	units := fs.Int("model-units", 1, "number of model units to purchase")
+	commitment := fs.String("commitment", "", "commitment duration - OneMonth or SixMonths (no commitment if empty)")
+	fs.Parse(args)
+
+	if *name == "" || *modelID == "" {
+		log.Fatal("name and model are required")
+	}
+
+	out, err := bc.CreateProvisionedModelThroughput(context.Background(), &bedrock.CreateProvisionedModelThroughputInput{
+		ProvisionedModelName: aws.String(*name),
+		ModelId:              aws.String(*modelID),
+		ModelUnits:           aws.Int32(int32(*units)),
+		CommitmentDuration:   types.CommitmentDuration(*commitment),
+	})
+
+	if err != nil {
+		fmt.Println("failed to create provisioned model throughput")
+		log.Fatal(err)
+	}
+
+	fmt.Println("created provisioned model throughput", aws.ToString(out.ProvisionedModelArn))
+}
+
+func listThroughputs(args []string) {
+
+	fs := flag.NewFlagSet("throughput list", flag.ExitOnError)
+	status := fs.String("status", "", "only list provisioned models with this status - Creating, InService, Updating or Failed")
+	jsonOutput := fs.Bool("json", false, "print the provisioned models as JSON")
+	fs.Parse(args)
+
+	input := &bedrock.ListProvisionedModelThroughputsInput{
+		StatusEquals: types.ProvisionedModelStatus(*status),
+	}
+
+	var summaries []types.ProvisionedModelSummary
+
+	p := bedrock.NewListProvisionedModelThroughputsPaginator(bc, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.Background())
+		if err != nil {
+			fmt.Println("failed to list provisioned model throughputs")
+			log.Fatal(err)
+		}
+		summaries = append(summaries, page.ProvisionedModelSummaries...)
+	}
+
+	if *jsonOutput {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tUNITS\tCOMMITMENT\tMODEL\tARN")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			aws.ToString(s.ProvisionedModelName),
+			s.Status,
+			aws.ToInt32(s.ModelUnits),
+			commitment(s.CommitmentDuration),
+			aws.ToString(s.ModelArn),
+			aws.ToString(s.ProvisionedModelArn))
+	}
+	w.Flush()
+}
+
+func describeThroughput(args []string) {
+
+	fs := flag.NewFlagSet("throughput describe", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the provisioned model as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . throughput describe [-json] PROVISIONED_MODEL_NAME_OR_ARN")
+	}
+
+	pm, err := bc.GetProvisionedModelThroughput(context.Background(), &bedrock.GetProvisionedModelThroughputInput{
+		ProvisionedModelId: aws.String(fs.Arg(0)),
+	})
+
+	if err != nil {
+		fmt.Println("failed to get provisioned model throughput")
+		log.Fatal(err)
+	}
+
+	if *jsonOutput {
+		printJSON(pm)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", aws.ToString(pm.ProvisionedModelName))
+	fmt.Fprintf(w, "ARN:\t%s\n", aws.ToString(pm.ProvisionedModelArn))
+	fmt.Fprintf(w, "Status:\t%s\n", pm.Status)
+	fmt.Fprintf(w, "Model:\t%s\n", aws.ToString(pm.ModelArn))
+	fmt.Fprintf(w, "Foundation model:\t%s\n", aws.ToString(pm.FoundationModelArn))
+	fmt.Fprintf(w, "Model units:\t%d (desired %d)\n", aws.ToInt32(pm.ModelUnits), aws.ToInt32(pm.DesiredModelUnits))
+	fmt.Fprintf(w, "Commitment:\t%s\n", commitment(pm.CommitmentDuration))
+	fmt.Fprintf(w, "Commitment expires:\t%s\n", formatTime(pm.CommitmentExpirationTime))
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(pm.CreationTime))
+	if pm.FailureMessage != nil {
+		fmt.Fprintf(w, "Failure:\t%s\n", *pm.FailureMessage)
+	}
+	w.Flush()
+}
+
+// updateThroughput renames the provisioned model or associates it with a
+// different model (e.g. a newer version of a custom model).
+func updateThroughput(args []string) {
+
+	fs := flag.NewFlagSet("throughput update", flag.ExitOnError)
+	name := fs.String("name", "", "new name of the provisioned model")
+	modelID := fs.String("model", "", "ID or ARN of the new model to associate")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || (*name == "" && *modelID == "") {
+		log.Fatal("usage: go run . throughput update [-name NAME] [-model MODEL_ID] PROVISIONED_MODEL_NAME_OR_ARN")
+	}
+
+	input := &bedrock.UpdateProvisionedModelThroughputInput{
+		ProvisionedModelId: aws.String(fs.Arg(0)),
+	}
+
+	if *name != "" {
+		input.DesiredProvisionedModelName = aws.String(*name)
+	}
+
+	if *modelID != "" {
+		input.DesiredModelId = aws.String(*modelID)
+	}
+
+	_, err := bc.UpdateProvisionedModelThroughput(context.Background(), input)
+	if err != nil {
+		fmt.Println("failed to update provisioned model throughput")
+		log.Fatal(err)
+	}
+
+	fmt.Println("updating provisioned model throughput", fs.Arg(0))
+}
+
+func deleteThroughput(args []string) {
+
+	fs := flag.NewFlagSet("throughput delete", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . throughput delete PROVISIONED_MODEL_NAME_OR_ARN")
+	}
+
+	_, err := bc.DeleteProvisionedModelThroughput(context.Background(), &bedrock.DeleteProvisionedModelThroughputInput{
+		ProvisionedModelId: aws.String(fs.Arg(0)),
+	})
+
+	if err != nil {
+		fmt.Println("failed to delete provisioned model throughput")
+		log.Fatal(err)
+	}
+
+	fmt.Println("deleted provisioned model throughput", fs.Arg(0))
+}
+
+func commitment(c types.CommitmentDuration) string {
+	if c == "" {
+		return "none"
+	}
+	return string(c)
+}
@@ -5,12 +5,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/guardrails"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
@@ -37,11 +39,21 @@ func init() {
 }
 
 var verbose *bool
+var guardrail *guardrails.Config
+var filters guardrails.Filters
 
 func main() {
 	verbose = flag.Bool("verbose", false, "setting to true will log messages being exchanged with LLM")
+	guardrail = guardrails.Flags(flag.CommandLine)
+	denyInput, denyOutput := guardrails.FilterFlags(flag.CommandLine)
 	flag.Parse()
 
+	var err error
+	filters, err = guardrails.LoadFilters(*denyInput, *denyOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
 	var chatHistory string
@@ -51,21 +63,39 @@ func main() {
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
+		err := filters.CheckInput(context.Background(), input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
 		msg := chatHistory + fmt.Sprintf(claudePromptFormat, input)
 
 		response, err := send(msg)
 
+		//blocked exchanges are left out of the chat history
+		var filterErr *guardrails.FilterError
+		if errors.As(err, &filterErr) {
+			fmt.Println("\n" + err.Error())
+			continue
+		}
+
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		chatHistory = msg + response
+		if response.Intervened() {
+			fmt.Println("\n---", response.Result, "---")
+			continue
+		}
+
+		chatHistory = msg + response.Completion
 	}
 }
 
 const claudePromptFormat = "\n\nHuman: %s\n\nAssistant:"
 
-func send(msg string) (string, error) {
+func send(msg string) (Response, error) {
 
 	if *verbose {
 		fmt.Println("[sending message]", msg)
@@ -75,29 +105,43 @@ func send(msg string) (string, error) {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", err
+		return Response{}, err
 	}
 
-	output, err := brc.InvokeModelWithResponseStream(context.Background(), &bedrockruntime.InvokeModelWithResponseStreamInput{
+	input := &bedrockruntime.InvokeModelWithResponseStreamInput{
 		Body:        payloadBytes,
 		ModelId:     aws.String("anthropic.claude-v2"),
 		ContentType: aws.String("application/json"),
-	})
+	}
+	input.GuardrailIdentifier, input.GuardrailVersion, input.Trace = guardrail.Apply()
+
+	output, err := brc.InvokeModelWithResponseStream(context.Background(), input)
 
 	if err != nil {
-		return "", err
+		return Response{}, err
 	}
 
+	//post filters are checked against the text streamed so far, so that
+	//output is cut off as soon as it matches
+	var streamed string
+
 	resp, err := processStreamingOutput(output, func(ctx context.Context, part []byte) error {
+		streamed += string(part)
+		err := filters.CheckOutput(ctx, streamed)
+		if err != nil {
+			return err
+		}
+
 		fmt.Print(string(part))
 		return nil
 	})
 
-	if err != nil {
+	var filterErr *guardrails.FilterError
+	if err != nil && !errors.As(err, &filterErr) {
 		log.Fatal("streaming output processing error: ", err)
 	}
 
-	return resp.Completion, nil
+	return resp, err
 }
 
 type StreamingOutputHandler func(ctx context.Context, part []byte) error
@@ -105,7 +149,7 @@ type StreamingOutputHandler func(ctx context.Context, part []byte) error
 func processStreamingOutput(output *bedrockruntime.InvokeModelWithResponseStreamOutput, handler StreamingOutputHandler) (Response, error) {
 
 	var combinedResult string
-	resp := Response{}
+	result := Response{}
 
 	for event := range output.GetStream().Events() {
 		switch v := event.(type) {
@@ -119,7 +163,16 @@ func processStreamingOutput(output *bedrockruntime.InvokeModelWithResponseStream
 				return resp, err
 			}
 
-			handler(context.Background(), []byte(resp.Completion))
+			//the guardrail action and trace are sent with the last chunk
+			if resp.Action != "" {
+				result.Result = resp.Result
+			}
+
+			err = handler(context.Background(), []byte(resp.Completion))
+			if err != nil {
+				output.GetStream().Close()
+				return result, err
+			}
 			combinedResult += resp.Completion
 
 		case *types.UnknownUnionMember:
@@ -130,9 +183,9 @@ func processStreamingOutput(output *bedrockruntime.InvokeModelWithResponseStream
 		}
 	}
 
-	resp.Completion = combinedResult
+	result.Completion = combinedResult
 
-	return resp, nil
+	return result, output.GetStream().Err()
 }
 
 // request/response model
@@ -147,4 +200,5 @@ type Request struct {
 
 type Response struct {
 	Completion string `json:"completion"`
+	guardrails.Result
 }
@@ -10,14 +10,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/guardrails"
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 )
 
 const defaultRegion = "us-east-1"
 
+const claudeV2ModelID = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+
 var brc *bedrockruntime.Client
+var bc *bedrock.Client
 
 func init() {
 
@@ -32,14 +38,36 @@ func init() {
 	}
 
 	brc = bedrockruntime.NewFromConfig(cfg)
+	bc = bedrock.NewFromConfig(cfg)
 }
 
 var verbose *bool
+var modelID *string
+var guardrail *guardrails.Config
+var filters guardrails.Filters
 
 func main() {
 	verbose = flag.Bool("verbose", false, "setting to true will log messages being exchanged with LLM")
+	modelID = flag.String("model", claudeV2ModelID, "Claude model ID or provisioned model ARN")
+	guardrail = guardrails.Flags(flag.CommandLine)
+	denyInput, denyOutput := guardrails.FilterFlags(flag.CommandLine)
 	flag.Parse()
 
+	var err error
+	filters, err = guardrails.LoadFilters(*denyInput, *denyOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	family, err := models.Resolve(context.Background(), bc, *modelID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if family != models.Claude {
+		log.Fatalf("model %s is a %s model - this example only supports Claude text completion models", *modelID, family)
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
 	var chatHistory string
@@ -49,6 +77,12 @@ func main() {
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
+		err := filters.CheckInput(context.Background(), input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
 		msg := chatHistory + fmt.Sprintf(claudePromptFormat, input)
 
 		response, err := send(msg)
@@ -57,16 +91,29 @@ func main() {
 			log.Fatal(err)
 		}
 
-		chatHistory = msg + response
+		//blocked exchanges are left out of the chat history
+		if response.Intervened() {
+			fmt.Println("\n---", response.Result, "---")
+			fmt.Println(response.Completion)
+			continue
+		}
+
+		err = filters.CheckOutput(context.Background(), response.Completion)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		chatHistory = msg + response.Completion
 
 		fmt.Println("\n--- Response ---")
-		fmt.Println(response)
+		fmt.Println(response.Completion)
 	}
 }
 
 const claudePromptFormat = "\n\nHuman: %s\n\nAssistant:"
 
-func send(msg string) (string, error) {
+func send(msg string) (Response, error) {
 
 	if *verbose {
 		fmt.Println("[sending message]", msg)
@@ -74,30 +121,29 @@ func send(msg string) (string, error) {
 
 	payload := Request{Prompt: msg, MaxTokensToSample: 2048}
 
+	var resp Response
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", err
+		return resp, err
 	}
 
-	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
+	input := &bedrockruntime.InvokeModelInput{
 		Body:        payloadBytes,
-		ModelId:     aws.String("anthropic.claude-v2"),
+		ModelId:     aws.String(*modelID),
 		ContentType: aws.String("application/json"),
-	})
-
-	if err != nil {
-		return "", err
 	}
+	input.GuardrailIdentifier, input.GuardrailVersion, input.Trace = guardrail.Apply()
 
-	var resp Response
-
-	err = json.Unmarshal(output.Body, &resp)
+	output, err := brc.InvokeModel(context.Background(), input)
 
 	if err != nil {
-		return "", err
+		return resp, err
 	}
 
-	return resp.Completion, nil
+	err = json.Unmarshal(output.Body, &resp)
+
+	return resp, err
 }
 
 //request/response model
@@ -113,4 +159,5 @@ type Request struct {
 
 type Response struct {
 	Completion string `json:"completion"`
+	guardrails.Result
 }
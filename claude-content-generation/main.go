@@ -1,15 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 )
 
 const defaultRegion = "us-east-1"
@@ -28,6 +34,10 @@ Please rewrite the above paragraph to make it understandable to a 5th grader.
 Please output your rewrite in <rewrite></rewrite> tags.`
 
 func main() {
+	modelID := flag.String("model", claudeV2ModelID, "Claude model ID or provisioned model ARN")
+	tag := flag.String("tag", "rewrite", "print only the content of this tag (the raw response if empty)")
+	stream := flag.Bool("stream", false, "stream the response, printing the tag content as it arrives")
+	flag.Parse()
 
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -41,6 +51,15 @@ func main() {
 
 	brc := bedrockruntime.NewFromConfig(cfg)
 
+	family, err := models.Resolve(context.Background(), bedrock.NewFromConfig(cfg), *modelID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if family != models.Claude {
+		log.Fatalf("model %s is a %s model - this example only supports Claude text completion models", *modelID, family)
+	}
+
 	payload := Request{
 		Prompt:            fmt.Sprintf(claudePromptFormat, prompt),
 		MaxTokensToSample: 2048,
@@ -54,9 +73,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if *stream {
+		streamResponse(brc, *modelID, payloadBytes, *tag)
+		return
+	}
+
 	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
 		Body:        payloadBytes,
-		ModelId:     aws.String(claudeV2ModelID),
+		ModelId:     aws.String(*modelID),
 		ContentType: aws.String("application/json"),
 	})
 
@@ -72,8 +96,112 @@ func main() {
 		log.Fatal("failed to unmarshal", err)
 	}
 
-	fmt.Println("response from LLM\n", resp.Completion)
+	if *tag == "" {
+		fmt.Println("response from LLM\n", resp.Completion)
+		return
+	}
+
+	contents, err := ExtractTag(resp.Completion, *tag)
+
+	var missing *MissingTagError
+	if errors.As(err, &missing) {
+		log.Println(err)
+		fmt.Println("response from LLM\n", resp.Completion)
+		return
+	}
+
+	for _, c := range contents {
+		fmt.Println(c)
+	}
+}
+
+// streamResponse prints the content of the tag as the response is streamed.
+func streamResponse(brc *bedrockruntime.Client, modelID string, payload []byte, tag string) {
+
+	output, err := brc.InvokeModelWithResponseStream(context.Background(), &bedrockruntime.InvokeModelWithResponseStreamInput{
+		Body:        payload,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		log.Fatal("failed to invoke model: ", err)
+	}
+
+	if tag == "" {
+		_, err = processStreamingOutput(output, func(ctx context.Context, part []byte) error {
+			fmt.Print(string(part))
+			return nil
+		})
+		if err != nil {
+			log.Fatal("streaming output processing error: ", err)
+		}
+		return
+	}
+
+	//repeated tags are printed on separate lines
+	last := 0
+	streamer := NewTagStreamer(tag, func(index int, part string) error {
+		if index != last {
+			fmt.Println()
+			last = index
+		}
+		fmt.Print(part)
+		return nil
+	})
+
+	resp, err := processStreamingOutput(output, func(ctx context.Context, part []byte) error {
+		return streamer.Write(string(part))
+	})
+
+	if err != nil {
+		log.Fatal("streaming output processing error: ", err)
+	}
+
+	err = streamer.Close()
+	if err != nil {
+		log.Println(err)
+		fmt.Println("response from LLM\n", resp.Completion)
+		return
+	}
+	fmt.Println()
+}
+
+type StreamingOutputHandler func(ctx context.Context, part []byte) error
+
+func processStreamingOutput(output *bedrockruntime.InvokeModelWithResponseStreamOutput, handler StreamingOutputHandler) (Response, error) {
+
+	var combinedResult string
+	result := Response{}
+
+	for event := range output.GetStream().Events() {
+		switch v := event.(type) {
+		case *types.ResponseStreamMemberChunk:
+
+			var resp Response
+			err := json.NewDecoder(bytes.NewReader(v.Value.Bytes)).Decode(&resp)
+			if err != nil {
+				return result, err
+			}
+
+			err = handler(context.Background(), []byte(resp.Completion))
+			if err != nil {
+				output.GetStream().Close()
+				return result, err
+			}
+			combinedResult += resp.Completion
+
+		case *types.UnknownUnionMember:
+			fmt.Println("unknown tag:", v.Tag)
+
+		default:
+			fmt.Println("union is nil or unknown type")
+		}
+	}
+
+	result.Completion = combinedResult
 
+	return result, output.GetStream().Err()
 }
 
 //request/response model
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tag is an XML-like element in model output, e.g. <rewrite>...</rewrite>.
+// Model output is not well-formed XML, so tags are matched by name only and
+// anything else (entities, stray angle brackets) is left as text.
+type Tag struct {
+	Name string
+	// Content is the raw text between the opening and closing tag, including
+	// any nested tags.
+	Content  string
+	Children []*Tag
+	// Closed is false if the closing tag was missing and the tag ended where
+	// its parent (or the text) ended.
+	Closed bool
+}
+
+// MissingTagError is returned when the expected tag is not in the output.
+type MissingTagError struct {
+	Name string
+}
+
+func (e *MissingTagError) Error() string {
+	return fmt.Sprintf("no <%s> tag found in the output", e.Name)
+}
+
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z_][\w.:-]*)(?:\s[^<>]*)?>`)
+
+type openTag struct {
+	tag   *Tag
+	start int
+}
+
+// ParseTags returns the top level tags in text. A closing tag closes the most
+// recent open tag with the same name, implicitly closing any tags opened
+// after it. Closing tags without a matching opening tag are ignored, and tags
+// still open at the end of the text end there.
+func ParseTags(text string) []*Tag {
+	var roots []*Tag
+	var stack []openTag
+
+	add := func(t *Tag) {
+		if len(stack) == 0 {
+			roots = append(roots, t)
+		} else {
+			parent := stack[len(stack)-1].tag
+			parent.Children = append(parent.Children, t)
+		}
+	}
+
+	//pops the stack down to (and excluding) index i, ending tags at end
+	unwind := func(i, end int) {
+		for len(stack) > i {
+			o := stack[len(stack)-1]
+			o.tag.Content = text[o.start:end]
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for _, m := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		closing := m[3] > m[2]
+		name := text[m[4]:m[5]]
+
+		if !closing {
+			t := &Tag{Name: name}
+			add(t)
+			stack = append(stack, openTag{tag: t, start: m[1]})
+			continue
+		}
+
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].tag.Name == name {
+				unwind(i+1, m[0])
+				stack[i].tag.Closed = true
+				unwind(i, m[0])
+				break
+			}
+		}
+	}
+
+	unwind(0, len(text))
+
+	return roots
+}
+
+// FindTags returns every tag with the given name, including nested ones, in
+// the order in which they appear.
+func FindTags(tags []*Tag, name string) []*Tag {
+	var found []*Tag
+	for _, t := range tags {
+		if t.Name == name {
+			found = append(found, t)
+		}
+		found = append(found, FindTags(t.Children, name)...)
+	}
+	return found
+}
+
+// ExtractTag returns the content of every occurrence of the named tag, or a
+// MissingTagError if there is none.
+func ExtractTag(text, name string) ([]string, error) {
+	tags := FindTags(ParseTags(text), name)
+	if len(tags) == 0 {
+		return nil, &MissingTagError{Name: name}
+	}
+
+	contents := make([]string, 0, len(tags))
+	for _, t := range tags {
+		contents = append(contents, strings.TrimSpace(t.Content))
+	}
+	return contents, nil
+}
+
+// TagHandler receives the content of a tag as it is streamed. index counts
+// the occurrences of the tag in the output.
+type TagHandler func(index int, part string) error
+
+// TagStreamer passes only the content of the target tag to its handler, as
+// chunks of output are written to it. Text that could be the start of an
+// opening or closing tag is held back until the next chunk disambiguates it.
+// Only the bare form of the tag (<name>) is recognised, and nested tags with
+// the same name are passed through as content.
+type TagStreamer struct {
+	open, close string
+	handler     TagHandler
+
+	buf    string
+	inside bool
+	depth  int
+	count  int
+}
+
+func NewTagStreamer(name string, handler TagHandler) *TagStreamer {
+	return &TagStreamer{open: "<" + name + ">", close: "</" + name + ">", handler: handler}
+}
+
+func (s *TagStreamer) Write(chunk string) error {
+	s.buf += chunk
+
+	for {
+		if !s.inside {
+			i := strings.Index(s.buf, s.open)
+			if i < 0 {
+				s.buf = s.buf[s.holdBack():]
+				return nil
+			}
+			s.buf = s.buf[i+len(s.open):]
+			s.inside = true
+			continue
+		}
+
+		open, close := strings.Index(s.buf, s.open), strings.Index(s.buf, s.close)
+
+		switch {
+		case close < 0 && open < 0:
+			h := s.holdBack()
+			err := s.emit(s.buf[:h])
+			s.buf = s.buf[h:]
+			return err
+		case open >= 0 && (close < 0 || open < close):
+			s.depth++
+			err := s.emit(s.buf[:open+len(s.open)])
+			if err != nil {
+				return err
+			}
+			s.buf = s.buf[open+len(s.open):]
+		case s.depth > 0:
+			s.depth--
+			err := s.emit(s.buf[:close+len(s.close)])
+			if err != nil {
+				return err
+			}
+			s.buf = s.buf[close+len(s.close):]
+		default:
+			err := s.emit(s.buf[:close])
+			if err != nil {
+				return err
+			}
+			s.buf = s.buf[close+len(s.close):]
+			s.inside = false
+			s.count++
+		}
+	}
+}
+
+// Close flushes held back content of an unclosed tag, and returns a
+// MissingTagError if the tag never appeared.
+func (s *TagStreamer) Close() error {
+	if s.inside {
+		err := s.emit(s.buf)
+		s.buf = ""
+		s.inside = false
+		s.count++
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.count == 0 {
+		return &MissingTagError{Name: strings.Trim(s.open, "<>")}
+	}
+	return nil
+}
+
+// Count returns the number of occurrences of the tag seen so far.
+func (s *TagStreamer) Count() int {
+	return s.count
+}
+
+func (s *TagStreamer) emit(part string) error {
+	if part == "" {
+		return nil
+	}
+	return s.handler(s.count, part)
+}
+
+// holdBack returns the index from which the buffer could be the start of the
+// opening or closing tag.
+func (s *TagStreamer) holdBack() int {
+	i := strings.LastIndex(s.buf, "<")
+	if i < 0 {
+		return len(s.buf)
+	}
+
+	rest := s.buf[i:]
+	if strings.HasPrefix(s.open, rest) || strings.HasPrefix(s.close, rest) {
+		return i
+	}
+	return len(s.buf)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// stream writes the chunks to a TagStreamer for the rewrite tag, and returns
+// the content received for each occurrence.
+func stream(t *testing.T, chunks ...string) ([]string, error) {
+	t.Helper()
+
+	var got []string
+	s := NewTagStreamer("rewrite", func(index int, part string) error {
+		for len(got) <= index {
+			got = append(got, "")
+		}
+		got[index] += part
+		return nil
+	})
+
+	for _, c := range chunks {
+		err := s.Write(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return got, s.Close()
+}
+
+func TestTagStreamer(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{"single chunk", []string{"intro <rewrite>hello</rewrite> outro"}, []string{"hello"}},
+		{"opening tag across two chunks", []string{"intro <rew", "rite>hello</rewrite>"}, []string{"hello"}},
+		{"opening tag across three chunks", []string{"<re", "wri", "te>hello</rewrite>"}, []string{"hello"}},
+		{"closing tag across three chunks", []string{"<rewrite>hello</", "rew", "rite> outro"}, []string{"hello"}},
+		{"missing closing tag", []string{"<rewrite>hello", " world"}, []string{"hello world"}},
+		{"missing closing tag ending in <", []string{"<rewrite>a <"}, []string{"a <"}},
+		{"repeated tags", []string{"<rewrite>a</rewrite> and <rewrite>", "b</rewrite>"}, []string{"a", "b"}},
+		{"nested tag with the same name", []string{"<rewrite>a<rewrite>b</rew", "rite>c</rewrite>"}, []string{"a<rewrite>b</rewrite>c"}},
+		{"nested tag with another name", []string{"<rewrite><b>bold</b></rewrite>"}, []string{"<b>bold</b>"}},
+		{"< at the end of a chunk inside the tag", []string{"<rewrite>1 <", " 2</rewrite>"}, []string{"1 < 2"}},
+		{"< at the end of a chunk outside the tag", []string{"1 <", " 2 <rewrite>x</rewrite>"}, []string{"x"}},
+		{"< that starts another tag", []string{"<rewrite>a <", "b>bold</b></rewrite>"}, []string{"a <b>bold</b>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stream(t, tt.chunks...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagStreamerByteChunks(t *testing.T) {
+	output := "a < b <rewrite>x <i>y</i> <rewrite>z</rewrite></rewrite> c <rewrite>2</rewrite>"
+
+	var chunks []string
+	for i := range output {
+		chunks = append(chunks, output[i:i+1])
+	}
+
+	got, err := stream(t, chunks...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"x <i>y</i> <rewrite>z</rewrite>", "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTagStreamerMissingTag(t *testing.T) {
+	_, err := stream(t, "no tags <here", "> at all")
+
+	var missing *MissingTagError
+	if !errors.As(err, &missing) || missing.Name != "rewrite" {
+		t.Fatalf("got error %v, want a MissingTagError", err)
+	}
+}
+
+func TestExtractTag(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single", "Here it is: <rewrite> hello </rewrite>", []string{"hello"}},
+		{"repeated", "<rewrite>a</rewrite><rewrite>b</rewrite>", []string{"a", "b"}},
+		{"nested", "<answer><rewrite>a</rewrite></answer>", []string{"a"}},
+		{"missing closing tag", "<rewrite>a <b>bold</b>", []string{"a <b>bold</b>"}},
+		{"attributes and stray brackets", `<rewrite tone="formal">1 < 2 & 3 > 2</rewrite>`, []string{"1 < 2 & 3 > 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractTag(tt.text, "rewrite")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, err := ExtractTag("<other>a</other>", "rewrite")
+	var missing *MissingTagError
+	if !errors.As(err, &missing) {
+		t.Fatalf("got error %v, want a MissingTagError", err)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags := ParseTags("<a>1<b>2</a> </c> <a>3<a>4</a></a>")
+
+	if len(tags) != 2 {
+		t.Fatalf("got %d top level tags, want 2", len(tags))
+	}
+
+	first := tags[0]
+	if first.Content != "1<b>2" || !first.Closed {
+		t.Fatalf("got first tag %+v", first)
+	}
+	if len(first.Children) != 1 || first.Children[0].Content != "2" || first.Children[0].Closed {
+		t.Fatalf("unclosed <b> should end where its parent ends, got %+v", first.Children)
+	}
+
+	second := tags[1]
+	if second.Content != "3<a>4</a>" || len(second.Children) != 1 || second.Children[0].Content != "4" {
+		t.Fatalf("got second tag %+v", second)
+	}
+
+	if got := len(FindTags(tags, "a")); got != 3 {
+		t.Fatalf("found %d <a> tags, want 3", got)
+	}
+}
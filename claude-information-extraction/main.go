@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"strings"
 
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/jsonschema"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
@@ -19,18 +23,59 @@ const (
 	claudeV2ModelID    = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
 )
 
-const prompt = `<directory>
-Phone directory:
+const document = `Phone directory:
 John Latrabe, 800-232-1995, john909709@geemail.com
 Josie Lana, 800-759-2905, josie@josielananier.com
 Keven Stevens, 800-980-7000, drkevin22@geemail.com 
-Phone directory will be kept up to date by the HR manager."
-<directory>
+Phone directory will be kept up to date by the HR manager."`
 
-Please output the email addresses within the directory, one per line, in the order in which they appear within the text. If there are no email addresses in the text, output "N/A".`
+const promptFormat = `<document>
+%s
+</document>
+
+Extract the information in the document that matches this JSON schema:
+<schema>
+%s
+</schema>
+
+%s Only include fields defined in the schema, and don't output anything else.`
+
+const repairPromptFormat = `Your output doesn't match the schema:
+%s
+
+Please output the corrected result. %s`
+
+// Directory is the default extraction target. Pass -schema to extract
+// different fields.
+type Directory struct {
+	Contacts []Contact `json:"contacts" description:"people listed in the directory, in the order in which they appear"`
+	Owner    string    `json:"owner,omitempty" description:"who keeps the directory up to date"`
+}
+
+type Contact struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone" pattern:"^[0-9+() .-]{7,}$"`
+	Email string `json:"email,omitempty" pattern:"^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$"`
+}
+
+var brc *bedrockruntime.Client
 
 func main() {
 
+	redact := flag.Bool("redact", true, "replace emails, phone numbers and credit card numbers with placeholders before sending the prompt")
+	var patterns patternFlag
+	flag.Var(&patterns, "pattern", "additional NAME=REGEX to redact (can be repeated)")
+	documentFile := flag.String("document", "", "file with the text to extract information from (defaults to a sample phone directory)")
+	schemaFile := flag.String("schema", "", "JSON Schema file describing the fields to extract (defaults to the Directory struct)")
+	format := flag.String("format", "json", "output format requested from the model - json or xml")
+	retries := flag.Int("retries", 2, "number of times to re-prompt the model when its output doesn't match the schema")
+	verbose := flag.Bool("verbose", false, "print the prompts as sent to the model")
+	flag.Parse()
+
+	if *format != "json" && *format != "xml" {
+		log.Fatal("format must be json or xml")
+	}
+
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
 		region = defaultRegion
@@ -41,10 +86,121 @@ func main() {
 		log.Fatal(err)
 	}
 
-	brc := bedrockruntime.NewFromConfig(cfg)
+	brc = bedrockruntime.NewFromConfig(cfg)
+
+	text := document
+	if *documentFile != "" {
+		b, err := os.ReadFile(*documentFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		text = string(b)
+	}
+
+	var schema *jsonschema.Schema
+	if *schemaFile != "" {
+		schema, err = loadSchema(*schemaFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		schema = schemaFor(reflect.TypeOf(Directory{}))
+	}
+
+	validator, err := schema.Compile()
+	if err != nil {
+		log.Fatal("invalid schema: ", err)
+	}
+
+	//raw PII never leaves the process - the model only sees placeholders
+	var redactor *Redactor
+	if *redact {
+		redactor = NewRedactor(append([]Detector{CreditCardDetector, EmailDetector, PhoneDetector}, patterns...)...)
+		text = redactor.Redact(text)
+		log.Println("redacted values:", redactor.Redacted())
+	}
+
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conversation := fmt.Sprintf(claudePromptFormat, fmt.Sprintf(promptFormat, text, schemaJSON, formatInstructions(*format)))
+
+	var result interface{}
+
+	for attempt := 0; ; attempt++ {
+		if *verbose {
+			fmt.Println("prompt sent to LLM\n", conversation)
+		}
+
+		completion, err := send(conversation)
+		if err != nil {
+			log.Fatal("failed to invoke model: ", err)
+		}
+
+		//placeholders are restored before validation so that patterns apply
+		//to the real values, but the conversation stays redacted
+		restored := completion
+		if redactor != nil {
+			restored = redactor.Restore(completion)
+		}
+
+		var errs []string
+		result, err = parseOutput(*format, restored, schema)
+		if err != nil {
+			errs = []string{err.Error()}
+		} else {
+			errs = validator.Validate("$", result)
+		}
+
+		if len(errs) == 0 {
+			break
+		}
+
+		log.Printf("attempt %d: output doesn't match the schema:\n- %s", attempt+1, strings.Join(errs, "\n- "))
+
+		if attempt == *retries {
+			log.Fatalf("no valid output after %d attempts", attempt+1)
+		}
+
+		conversation += completion + fmt.Sprintf(claudePromptFormat, fmt.Sprintf(repairPromptFormat, "- "+strings.Join(errs, "\n- "), formatInstructions(*format)))
+	}
+
+	if *schemaFile != "" {
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	//round trip through JSON to decode into the struct
+	b, err := json.Marshal(result)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var directory Directory
+	err = json.Unmarshal(b, &directory)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("extracted contacts:")
+	for _, c := range directory.Contacts {
+		fmt.Printf("%s | %s | %s\n", c.Name, c.Phone, c.Email)
+	}
+	if directory.Owner != "" {
+		fmt.Println("directory owner:", directory.Owner)
+	}
+}
+
+func send(prompt string) (string, error) {
 
 	payload := Request{
-		Prompt:            fmt.Sprintf(claudePromptFormat, prompt),
+		Prompt:            prompt,
 		MaxTokensToSample: 2048,
 		Temperature:       0.5,
 		TopK:              250,
@@ -53,7 +209,7 @@ func main() {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	//log.Println("raw request", string(payloadBytes))
@@ -65,7 +221,7 @@ func main() {
 	})
 
 	if err != nil {
-		log.Fatal("failed to invoke model: ", err)
+		return "", err
 	}
 
 	//log.Println("raw response ", string(output.Body))
@@ -74,12 +230,7 @@ func main() {
 
 	err = json.Unmarshal(output.Body, &resp)
 
-	if err != nil {
-		log.Fatal("failed to unmarshal", err)
-	}
-
-	fmt.Println("response from LLM\n", resp.Completion)
-
+	return resp.Completion, err
 }
 
 //request/response model
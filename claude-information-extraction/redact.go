@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector finds sensitive values in text.
+type Detector interface {
+	// Label is used in the placeholders of the values found by the detector.
+	Label() string
+	// Find returns the start and end index of every value in text.
+	Find(text string) [][]int
+}
+
+// RegexDetector detects values matching a regular expression. Matches are
+// discarded if Validate is set and returns false.
+type RegexDetector struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Validate func(match string) bool
+}
+
+func (d RegexDetector) Label() string {
+	return d.Name
+}
+
+func (d RegexDetector) Find(text string) [][]int {
+	var found [][]int
+	for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+		if d.Validate == nil || d.Validate(text[loc[0]:loc[1]]) {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+var (
+	EmailDetector = RegexDetector{
+		Name:    "EMAIL",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+
+	PhoneDetector = RegexDetector{
+		Name:    "PHONE",
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[-.\s]?)?(?:\(\d{3}\)\s?|\b\d{3}[-.\s])\d{3}[-.\s]\d{4}\b`),
+	}
+
+	CreditCardDetector = RegexDetector{
+		Name:     "CREDIT_CARD",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: luhn,
+	}
+)
+
+// luhn reports whether the digits in s have a valid Luhn checksum.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// Redactor replaces the values found by its detectors with placeholders such
+// as [EMAIL_1]. The same value always gets the same placeholder, so a
+// Redactor can be used across several prompts of a conversation, and the
+// placeholders in the model response can be restored to the original values.
+type Redactor struct {
+	Detectors []Detector
+
+	placeholders map[string]string
+	values       map[string]string
+	counts       map[string]int
+}
+
+func NewRedactor(detectors ...Detector) *Redactor {
+	return &Redactor{
+		Detectors:    detectors,
+		placeholders: map[string]string{},
+		values:       map[string]string{},
+		counts:       map[string]int{},
+	}
+}
+
+type match struct {
+	start, end int
+	label      string
+}
+
+// Redact returns text with every detected value replaced by its placeholder.
+func (r *Redactor) Redact(text string) string {
+
+	var matches []match
+	for _, d := range r.Detectors {
+		for _, loc := range d.Find(text) {
+			matches = append(matches, match{loc[0], loc[1], d.Label()})
+		}
+	}
+
+	//the earliest, then longest, match wins where matches overlap
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+
+	var b strings.Builder
+	pos := 0
+
+	for _, m := range matches {
+		if m.start < pos {
+			continue
+		}
+
+		b.WriteString(text[pos:m.start])
+		b.WriteString(r.placeholder(m.label, text[m.start:m.end]))
+		pos = m.end
+	}
+	b.WriteString(text[pos:])
+
+	return b.String()
+}
+
+func (r *Redactor) placeholder(label, value string) string {
+	key := label + "\x00" + value
+	if p, ok := r.placeholders[key]; ok {
+		return p
+	}
+
+	r.counts[label]++
+	p := fmt.Sprintf("[%s_%d]", label, r.counts[label])
+
+	r.placeholders[key] = p
+	r.values[p] = value
+
+	return p
+}
+
+// Restore replaces the placeholders in text with the original values.
+func (r *Redactor) Restore(text string) string {
+	pairs := make([]string, 0, 2*len(r.values))
+	for p, v := range r.values {
+		pairs = append(pairs, p, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Redacted returns the number of distinct values redacted so far, by label.
+func (r *Redactor) Redacted() map[string]int {
+	counts := map[string]int{}
+	for k, v := range r.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// patternFlag collects custom detectors given as NAME=REGEX.
+type patternFlag []Detector
+
+func (p *patternFlag) String() string {
+	labels := make([]string, 0, len(*p))
+	for _, d := range *p {
+		labels = append(labels, d.Label())
+	}
+	return strings.Join(labels, ",")
+}
+
+func (p *patternFlag) Set(value string) error {
+	name, expr, ok := strings.Cut(value, "=")
+	if !ok || name == "" || expr == "" {
+		return fmt.Errorf("expected NAME=REGEX, got %q", value)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	*p = append(*p, RegexDetector{Name: strings.ToUpper(name), Pattern: re})
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"4111 1111 1111 1111", true},
+		{"4111-1111-1111-1111", true},
+		{"378282246310005", true},
+		{"4111111111111112", false},
+		{"1234567812345678", false},
+		{"79927398713", false}, //valid checksum, but too short for a card number
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := luhn(tt.number); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name      string
+		detectors []Detector
+		text      string
+		want      string
+	}{
+		{
+			name:      "email and phone",
+			detectors: []Detector{EmailDetector, PhoneDetector},
+			text:      "mail jane@example.com or call 555-123-4567",
+			want:      "mail [EMAIL_1] or call [PHONE_1]",
+		},
+		{
+			name:      "valid card number",
+			detectors: []Detector{CreditCardDetector},
+			text:      "card 4111 1111 1111 1111 on file",
+			want:      "card [CREDIT_CARD_1] on file",
+		},
+		{
+			name:      "invalid card number",
+			detectors: []Detector{CreditCardDetector},
+			text:      "order 4111 1111 1111 1112 shipped",
+			want:      "order 4111 1111 1111 1112 shipped",
+		},
+		{
+			name:      "repeated value",
+			detectors: []Detector{EmailDetector},
+			text:      "jane@example.com, bob@example.com, jane@example.com",
+			want:      "[EMAIL_1], [EMAIL_2], [EMAIL_1]",
+		},
+		{
+			name:      "phone inside card number",
+			detectors: []Detector{PhoneDetector, CreditCardDetector},
+			text:      "card 411111 111 111 1111",
+			want:      "card [CREDIT_CARD_1]",
+		},
+		{
+			name:      "longest match at the same position",
+			detectors: []Detector{RegexDetector{Name: "NAME", Pattern: regexp.MustCompile(`jane`)}, EmailDetector},
+			text:      "jane@example.com",
+			want:      "[EMAIL_1]",
+		},
+		{
+			name:      "earliest match",
+			detectors: []Detector{RegexDetector{Name: "DOMAIN", Pattern: regexp.MustCompile(`example\.com`)}, EmailDetector},
+			text:      "jane@example.com and example.com",
+			want:      "[EMAIL_1] and [DOMAIN_1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedactor(tt.detectors...)
+
+			got := r.Redact(tt.text)
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+
+			if restored := r.Restore(got); restored != tt.text {
+				t.Fatalf("restored %q, want %q", restored, tt.text)
+			}
+		})
+	}
+}
+
+func TestRedactorAcrossPrompts(t *testing.T) {
+	r := NewRedactor(EmailDetector)
+
+	first := r.Redact("from jane@example.com")
+	second := r.Redact("reply to bob@example.com and jane@example.com")
+
+	if first != "from [EMAIL_1]" || second != "reply to [EMAIL_2] and [EMAIL_1]" {
+		t.Fatalf("got %q and %q", first, second)
+	}
+
+	if got := r.Restore("sent to [EMAIL_2]"); got != "sent to bob@example.com" {
+		t.Fatalf("got %q", got)
+	}
+
+	if got := r.Redacted()["EMAIL"]; got != 2 {
+		t.Fatalf("got %d redacted emails, want 2", got)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/jsonschema"
+)
+
+func loadSchema(file string) (*jsonschema.Schema, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var s jsonschema.Schema
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", file, err)
+	}
+
+	return &s, nil
+}
+
+// schemaFor derives a schema from a Go type. Struct fields are named after
+// their json tag and are required unless the tag has omitempty. The
+// description, pattern and enum (comma separated) tags are copied to the
+// schema.
+func schemaFor(t reflect.Type) *jsonschema.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}}
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			p := schemaFor(f.Type)
+			p.Description = f.Tag.Get("description")
+			p.Pattern = f.Tag.Get("pattern")
+			if enum := f.Tag.Get("enum"); enum != "" {
+				p.Enum = strings.Split(enum, ",")
+			}
+
+			s.Properties[name] = p
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return &jsonschema.Schema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonschema.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonschema.Schema{Type: "number"}
+	default:
+		return &jsonschema.Schema{Type: "string"}
+	}
+}
+
+// formatInstructions tells the model how to lay out its answer.
+func formatInstructions(format string) string {
+	if format == "xml" {
+		return `Output the result as XML inside <result></result> tags. Use an element named after each field, and represent arrays as one <item> element per entry.`
+	}
+	return `Output the result as a single JSON object inside <json></json> tags.`
+}
+
+// parseOutput decodes the model output in the given format into a generic JSON
+// value.
+func parseOutput(format, output string, s *jsonschema.Schema) (interface{}, error) {
+	if format == "xml" {
+		body, ok := between(output, "<result>", "</result>")
+		if !ok {
+			return nil, fmt.Errorf("no <result> element found in the output")
+		}
+
+		d := xml.NewDecoder(strings.NewReader("<result>" + body + "</result>"))
+		start, err := nextStart(d)
+		if err != nil {
+			return nil, err
+		}
+		return xmlValue(d, start, s)
+	}
+
+	body, ok := between(output, "<json>", "</json>")
+	if !ok {
+		//fall back to the outermost braces
+		start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+		if start < 0 || end < start {
+			return nil, fmt.Errorf("no JSON object found in the output")
+		}
+		body = output[start : end+1]
+	}
+
+	var v interface{}
+	err := json.Unmarshal([]byte(body), &v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, nil
+}
+
+func between(s, open, close string) (string, bool) {
+	start := strings.Index(s, open)
+	if start < 0 {
+		return "", false
+	}
+	s = s[start+len(open):]
+
+	end := strings.Index(s, close)
+	if end < 0 {
+		return "", false
+	}
+	return s[:end], true
+}
+
+func nextStart(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// xmlValue decodes the element that was just started into the JSON value
+// described by the schema.
+func xmlValue(d *xml.Decoder, start xml.StartElement, s *jsonschema.Schema) (interface{}, error) {
+	switch s.Type {
+	case "object", "array":
+		obj := map[string]interface{}{}
+		var items []interface{}
+
+		for {
+			t, err := d.Token()
+			if err == io.EOF {
+				return nil, fmt.Errorf("element <%s> is not closed", start.Name.Local)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			switch t := t.(type) {
+			case xml.StartElement:
+				child := s.Items
+				if s.Type == "object" {
+					child = s.Properties[t.Name.Local]
+				}
+
+				if child == nil {
+					//keep unknown fields so that validation reports them
+					if s.Type == "object" {
+						obj[t.Name.Local] = ""
+					}
+					err = d.Skip()
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+
+				v, err := xmlValue(d, t, child)
+				if err != nil {
+					return nil, err
+				}
+
+				if s.Type == "object" {
+					obj[t.Name.Local] = v
+				} else {
+					items = append(items, v)
+				}
+			case xml.EndElement:
+				if s.Type == "object" {
+					return obj, nil
+				}
+				if items == nil {
+					items = []interface{}{}
+				}
+				return items, nil
+			}
+		}
+	}
+
+	var text strings.Builder
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := t.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			return nil, fmt.Errorf("unexpected element <%s> in <%s>", t.Name.Local, start.Name.Local)
+		case xml.EndElement:
+			return scalar(s.Type, strings.TrimSpace(text.String())), nil
+		}
+	}
+}
+
+// scalar converts element text to the JSON type of the schema. Text that
+// doesn't convert is kept as a string, so validation reports the type error.
+func scalar(typ, text string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+
+// functionCallsPrompt describes the XML function call format to models that
+// don't support the Messages API tools parameter.
+const functionCallsPrompt = `In this environment you have access to a set of tools you can use to answer the user's question.
+
+You may call them like this:
+<function_calls>
+<invoke>
+<tool_name>$TOOL_NAME</tool_name>
+<parameters>
+<$PARAMETER_NAME>$PARAMETER_VALUE</$PARAMETER_NAME>
+...
+</parameters>
+</invoke>
+</function_calls>
+
+The results will be returned in <function_results></function_results> tags.
+
+Here are the tools available:
+<tools>
+%s
+</tools>`
+
+const functionCallsEnd = "</function_calls>"
+
+var (
+	invokePattern   = regexp.MustCompile(`(?s)<invoke>(.*?)</invoke>`)
+	toolNameTag     = regexp.MustCompile(`(?s)<tool_name>\s*(.*?)\s*</tool_name>`)
+	parametersTag   = regexp.MustCompile(`(?s)<parameters>(.*?)</parameters>`)
+	openingTagRegex = regexp.MustCompile(`<([A-Za-z_][\w.-]*)>`)
+)
+
+// runFunctionCalls runs the conversation with a text completions model,
+// stopping generation at the end of each <function_calls> block to execute
+// the calls and append the results, until the model answers without calling
+// a function or maxIterations completions have been received.
+func runFunctionCalls(ctx context.Context, modelID string, registry *Registry, prompt string, maxIterations int) (string, error) {
+
+	conversation := fmt.Sprintf(claudePromptFormat, systemPrompt+"\n\n"+fmt.Sprintf(functionCallsPrompt, describeTools(registry))+"\n\n"+prompt)
+
+	//the final answer is everything the model wrote outside of function calls
+	var answer strings.Builder
+
+	for i := 0; i < maxIterations; i++ {
+
+		var resp ClaudeResponse
+		err := invoke(ctx, modelID, ClaudeRequest{
+			Prompt:            conversation,
+			MaxTokensToSample: 2048,
+			StopSequences:     []string{functionCallsEnd},
+		}, &resp)
+
+		if err != nil {
+			return "", err
+		}
+
+		if resp.StopReason == "max_tokens" {
+			return "", fmt.Errorf("completion truncated at the maximum number of tokens")
+		}
+
+		if resp.StopReason != "stop_sequence" || resp.Stop != functionCallsEnd {
+			answer.WriteString(resp.Completion)
+			return strings.TrimSpace(answer.String()), nil
+		}
+
+		block := resp.Completion + functionCallsEnd
+		if start := strings.Index(block, "<function_calls>"); start > 0 {
+			answer.WriteString(block[:start])
+		}
+
+		results := executeFunctionCalls(ctx, registry, block)
+		conversation += block + "\n\n" + results + "\n\n"
+	}
+
+	return "", fmt.Errorf("no final answer after %d iterations", maxIterations)
+}
+
+// executeFunctionCalls runs every invoke in the block and formats the results
+// as a <function_results> block.
+func executeFunctionCalls(ctx context.Context, registry *Registry, block string) string {
+	var b strings.Builder
+	b.WriteString("<function_results>\n")
+
+	for _, m := range invokePattern.FindAllStringSubmatch(block, -1) {
+		name := ""
+		if t := toolNameTag.FindStringSubmatch(m[1]); t != nil {
+			name = t[1]
+		}
+
+		var params string
+		if p := parametersTag.FindStringSubmatch(m[1]); p != nil {
+			params = p[1]
+		}
+
+		output, err := callWithXMLParameters(ctx, registry, name, params)
+
+		if *verbose {
+			log.Printf("[tool] %s(%s) -> %s %v", name, strings.TrimSpace(params), output, err)
+		}
+
+		if err != nil {
+			fmt.Fprintf(&b, "<error>\n%s\n</error>\n", err)
+			continue
+		}
+
+		fmt.Fprintf(&b, "<result>\n<tool_name>%s</tool_name>\n<stdout>\n%s\n</stdout>\n</result>\n", name, output)
+	}
+
+	b.WriteString("</function_results>")
+	return b.String()
+}
+
+// callWithXMLParameters converts <name>value</name> parameters into the JSON
+// input of the tool, using the types in its schema.
+func callWithXMLParameters(ctx context.Context, registry *Registry, name, params string) (string, error) {
+	tool, ok := registry.Tool(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool %s", name)
+	}
+
+	input := map[string]json.RawMessage{}
+
+	for params != "" {
+		loc := openingTagRegex.FindStringSubmatchIndex(params)
+		if loc == nil {
+			break
+		}
+
+		param := params[loc[2]:loc[3]]
+		rest := params[loc[1]:]
+
+		end := strings.Index(rest, "</"+param+">")
+		if end < 0 {
+			return "", fmt.Errorf("parameter %s is not closed", param)
+		}
+
+		input[param] = jsonValue(tool.InputSchema.Properties[param].Type, strings.TrimSpace(rest[:end]))
+		params = rest[end+len("</"+param+">"):]
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	return registry.Call(ctx, name, b)
+}
+
+// jsonValue encodes the text of a parameter as the JSON type of its schema.
+// Text that doesn't parse as the type is passed as a string, so the tool can
+// report the error.
+func jsonValue(typ, text string) json.RawMessage {
+	switch typ {
+	case "number", "integer":
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.RawMessage(text)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return json.RawMessage(strconv.FormatBool(b))
+		}
+	case "array", "object":
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+
+	b, _ := json.Marshal(text)
+	return b
+}
+
+func describeTools(registry *Registry) string {
+	var b strings.Builder
+
+	for _, t := range registry.Tools() {
+		fmt.Fprintf(&b, "<tool_description>\n<tool_name>%s</tool_name>\n<description>%s</description>\n<parameters>\n", t.Name, t.Description)
+
+		names := make([]string, 0, len(t.InputSchema.Properties))
+		for name := range t.InputSchema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p := t.InputSchema.Properties[name]
+			desc := p.Description
+			if len(p.Enum) > 0 {
+				desc += " One of: " + strings.Join(p.Enum, ", ") + "."
+			}
+			fmt.Fprintf(&b, "<parameter>\n<name>%s</name>\n<type>%s</type>\n<description>%s</description>\n</parameter>\n", name, p.Type, strings.TrimSpace(desc))
+		}
+
+		b.WriteString("</parameters>\n</tool_description>\n")
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+//request/response model (text completions)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       float64  `json:"temperature,omitempty"`
+	TopP              float64  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+	StopReason string `json:"stop_reason"`
+	Stop       string `json:"stop"`
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// echoRegistry has a single tool that returns its JSON input.
+func echoRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	verbose = new(bool)
+
+	registry := NewRegistry()
+	err := registry.Register(Tool{
+		Name: "echo",
+		InputSchema: InputSchema{
+			Properties: map[string]Property{
+				"count":   {Type: "integer"},
+				"ratio":   {Type: "number"},
+				"enabled": {Type: "boolean"},
+				"tags":    {Type: "array"},
+				"name":    {Type: "string"},
+			},
+			Required: []string{"name"},
+		},
+		Func: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return string(input), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestJSONValue(t *testing.T) {
+	tests := []struct {
+		typ, text, want string
+	}{
+		{"integer", "42", `42`},
+		{"number", "-3.5", `-3.5`},
+		{"number", "about 3", `"about 3"`},
+		{"boolean", "True", `true`},
+		{"boolean", "yes", `"yes"`},
+		{"array", `["a", "b"]`, `["a", "b"]`},
+		{"array", `[a, b]`, `"[a, b]"`},
+		{"object", `{"k": 1}`, `{"k": 1}`},
+		{"string", "42", `"42"`},
+		{"", `say "hi"`, `"say \"hi\""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.text, func(t *testing.T) {
+			if got := string(jsonValue(tt.typ, tt.text)); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallWithXMLParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    string
+		params  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "typed values",
+			tool:   "echo",
+			params: "\n<name> Bob </name>\n<count>3</count>\n<ratio>0.5</ratio>\n<enabled>false</enabled>\n<tags>[\"x\",\"y\"]</tags>\n",
+			want:   `{"count":3,"enabled":false,"name":"Bob","ratio":0.5,"tags":["x","y"]}`,
+		},
+		{
+			name:   "value containing other tags",
+			tool:   "echo",
+			params: "<name><b>Bob</b></name>",
+			want:   `{"name":"\u003cb\u003eBob\u003c/b\u003e"}`,
+		},
+		{
+			name:    "unclosed parameter",
+			tool:    "echo",
+			params:  "<name>Bob</name><count>3",
+			wantErr: "parameter count is not closed",
+		},
+		{
+			name:    "missing required parameter",
+			tool:    "echo",
+			params:  "<count>3</count>",
+			wantErr: "missing required input for tool echo: name",
+		},
+		{
+			name:    "unknown tool",
+			tool:    "delete_everything",
+			params:  "<name>Bob</name>",
+			wantErr: "unknown tool delete_everything",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callWithXMLParameters(context.Background(), echoRegistry(t), tt.tool, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteFunctionCalls(t *testing.T) {
+	block := `I'll look both up.
+<function_calls>
+<invoke>
+<tool_name>echo</tool_name>
+<parameters>
+<name>first</name>
+</parameters>
+</invoke>
+<invoke>
+<tool_name>weather</tool_name>
+<parameters>
+<city>Paris</city>
+</parameters>
+</invoke>
+<invoke>
+<tool_name>echo</tool_name>
+<parameters>
+<name>second</name>
+<count>2</count>
+</parameters>
+</invoke>
+</function_calls>`
+
+	got := executeFunctionCalls(context.Background(), echoRegistry(t), block)
+
+	want := `<function_results>
+<result>
+<tool_name>echo</tool_name>
+<stdout>
+{"name":"first"}
+</stdout>
+</result>
+<error>
+unknown tool weather
+</error>
+<result>
+<tool_name>echo</tool_name>
+<stdout>
+{"count":2,"name":"second"}
+</stdout>
+</result>
+</function_results>`
+
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
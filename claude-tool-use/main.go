@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const defaultRegion = "us-east-1"
+
+const claude3SonnetModelID = "anthropic.claude-3-sonnet-20240229-v1:0" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+
+const systemPrompt = "You are a helpful assistant. Use the tools when they help you answer accurately, and don't make up tool results."
+
+const prompt = "What time is it in Tokyo right now, and what is the weather like in Seattle? Also, what is 1234 multiplied by 5678?"
+
+var brc *bedrockruntime.Client
+
+func init() {
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	brc = bedrockruntime.NewFromConfig(cfg)
+}
+
+var verbose *bool
+
+func main() {
+	modelID := flag.String("model", claude3SonnetModelID, "Claude model ID")
+	mode := flag.String("mode", "auto", "tool calling format - messages (Messages API tool_use blocks), xml (function calls in text completions) or auto (messages for Claude 3 models)")
+	maxIterations := flag.Int("max-iterations", 5, "maximum number of model responses before giving up")
+	text := flag.String("prompt", prompt, "question for the model")
+	verbose = flag.Bool("verbose", false, "log tool calls and results")
+	flag.Parse()
+
+	if !strings.HasPrefix(*modelID, "anthropic.claude") {
+		log.Fatalf("unsupported model %s - this example only supports Claude", *modelID)
+	}
+
+	if *mode == "auto" {
+		*mode = "xml"
+		if strings.HasPrefix(*modelID, "anthropic.claude-3") {
+			*mode = "messages"
+		}
+	}
+
+	registry := NewRegistry()
+	for _, t := range exampleTools() {
+		err := registry.Register(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var answer string
+	var err error
+
+	switch *mode {
+	case "messages":
+		answer, err = runMessages(context.Background(), *modelID, registry, *text, *maxIterations)
+	case "xml":
+		answer, err = runFunctionCalls(context.Background(), *modelID, registry, *text, *maxIterations)
+	default:
+		log.Fatal("mode must be messages, xml or auto")
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("response from LLM\n", answer)
+}
+
+func invoke(ctx context.Context, modelID string, payload, resp interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	output, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to invoke model: %w", err)
+	}
+
+	return json.Unmarshal(output.Body, resp)
+}
+
+// exampleTools stand in for internal functions an agent would call.
+func exampleTools() []Tool {
+	return []Tool{
+		{
+			Name:        "get_current_time",
+			Description: "Returns the current date and time in an IANA time zone.",
+			InputSchema: InputSchema{
+				Properties: map[string]Property{
+					"timezone": {Type: "string", Description: "IANA time zone name, e.g. Asia/Tokyo"},
+				},
+				Required: []string{"timezone"},
+			},
+			Func: func(ctx context.Context, input json.RawMessage) (string, error) {
+				var in struct {
+					Timezone string `json:"timezone"`
+				}
+				err := json.Unmarshal(input, &in)
+				if err != nil {
+					return "", err
+				}
+
+				loc, err := time.LoadLocation(in.Timezone)
+				if err != nil {
+					return "", err
+				}
+				return time.Now().In(loc).Format(time.RFC1123), nil
+			},
+		},
+		{
+			Name:        "get_weather",
+			Description: "Returns the current weather for a city.",
+			InputSchema: InputSchema{
+				Properties: map[string]Property{
+					"city": {Type: "string", Description: "name of the city"},
+					"unit": {Type: "string", Description: "temperature unit", Enum: []string{"celsius", "fahrenheit"}},
+				},
+				Required: []string{"city"},
+			},
+			Func: func(ctx context.Context, input json.RawMessage) (string, error) {
+				var in struct {
+					City string `json:"city"`
+					Unit string `json:"unit"`
+				}
+				err := json.Unmarshal(input, &in)
+				if err != nil {
+					return "", err
+				}
+
+				//canned data - replace with a call to a weather service
+				temp := 14.0
+				if in.Unit == "fahrenheit" {
+					return fmt.Sprintf("%s: light rain, %.0f°F", in.City, temp*9/5+32), nil
+				}
+				return fmt.Sprintf("%s: light rain, %.0f°C", in.City, temp), nil
+			},
+		},
+		{
+			Name:        "calculator",
+			Description: "Performs an arithmetic operation on two numbers.",
+			InputSchema: InputSchema{
+				Properties: map[string]Property{
+					"operation": {Type: "string", Enum: []string{"add", "subtract", "multiply", "divide"}},
+					"a":         {Type: "number", Description: "first operand"},
+					"b":         {Type: "number", Description: "second operand"},
+				},
+				Required: []string{"operation", "a", "b"},
+			},
+			Func: func(ctx context.Context, input json.RawMessage) (string, error) {
+				var in struct {
+					Operation string  `json:"operation"`
+					A         float64 `json:"a"`
+					B         float64 `json:"b"`
+				}
+				err := json.Unmarshal(input, &in)
+				if err != nil {
+					return "", err
+				}
+
+				var result float64
+				switch in.Operation {
+				case "add":
+					result = in.A + in.B
+				case "subtract":
+					result = in.A - in.B
+				case "multiply":
+					result = in.A * in.B
+				case "divide":
+					if in.B == 0 {
+						return "", fmt.Errorf("division by zero")
+					}
+					result = in.A / in.B
+				default:
+					return "", fmt.Errorf("unknown operation %s", in.Operation)
+				}
+				return strconv.FormatFloat(result, 'f', -1, 64), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+const anthropicVersion = "bedrock-2023-05-31"
+
+// runMessages runs the conversation with the Messages API, executing the
+// tool_use blocks in each response and sending back the results, until the
+// model stops asking for tools or maxIterations responses have been
+// received.
+func runMessages(ctx context.Context, modelID string, registry *Registry, prompt string, maxIterations int) (string, error) {
+
+	var tools []MessagesTool
+	for _, t := range registry.Tools() {
+		tools = append(tools, MessagesTool{Name: t.Name, Description: t.Description, InputSchema: t.InputSchema})
+	}
+
+	messages := []Message{{Role: "user", Content: []ContentBlock{{Type: "text", Text: prompt}}}}
+
+	for i := 0; i < maxIterations; i++ {
+
+		var resp MessagesResponse
+		err := invoke(ctx, modelID, MessagesRequest{
+			AnthropicVersion: anthropicVersion,
+			MaxTokens:        2048,
+			System:           systemPrompt,
+			Messages:         messages,
+			Tools:            tools,
+		}, &resp)
+
+		if err != nil {
+			return "", err
+		}
+
+		messages = append(messages, Message{Role: "assistant", Content: resp.Content})
+
+		switch resp.StopReason {
+		case "tool_use":
+		case "max_tokens":
+			return "", fmt.Errorf("response truncated at the maximum number of tokens")
+		default:
+			return resp.text(), nil
+		}
+
+		var results []ContentBlock
+
+		for _, block := range resp.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+
+			result := ContentBlock{Type: "tool_result", ToolUseID: block.ID}
+
+			output, err := registry.Call(ctx, block.Name, block.Input)
+			if err != nil {
+				result.Content = err.Error()
+				result.IsError = true
+			} else {
+				result.Content = output
+			}
+
+			if *verbose {
+				log.Printf("[tool] %s(%s) -> %s", block.Name, block.Input, result.Content)
+			}
+
+			results = append(results, result)
+		}
+
+		if len(results) == 0 {
+			return "", fmt.Errorf("stop reason is tool_use, but the response has no tool_use blocks")
+		}
+
+		messages = append(messages, Message{Role: "user", Content: results})
+	}
+
+	return "", fmt.Errorf("no final answer after %d iterations", maxIterations)
+}
+
+//request/response model (Messages API)
+
+type MessagesRequest struct {
+	AnthropicVersion string         `json:"anthropic_version"`
+	MaxTokens        int            `json:"max_tokens"`
+	System           string         `json:"system,omitempty"`
+	Messages         []Message      `json:"messages"`
+	Tools            []MessagesTool `json:"tools,omitempty"`
+	Temperature      float64        `json:"temperature,omitempty"`
+}
+
+type MessagesTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema InputSchema `json:"input_schema"`
+}
+
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a text, tool_use or tool_result block.
+type ContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+
+	//tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	//tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+type MessagesResponse struct {
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+}
+
+func (r MessagesResponse) text() string {
+	var text string
+	for _, block := range r.Content {
+		if block.Type == "text" {
+			text += block.Text
+		}
+	}
+	return text
+}
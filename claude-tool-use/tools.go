@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tool is a Go function the model can call. The model sends the input as a
+// JSON object matching InputSchema.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema InputSchema
+	Func        func(ctx context.Context, input json.RawMessage) (string, error)
+}
+
+// InputSchema is the JSON Schema of a tool's input object.
+type InputSchema struct {
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties"`
+	Required   []string            `json:"required,omitempty"`
+}
+
+type Property struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Registry holds the tools advertised to the model, in registration order.
+type Registry struct {
+	tools map[string]Tool
+	names []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tools: map[string]Tool{}}
+}
+
+func (r *Registry) Register(t Tool) error {
+	if !toolNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid tool name %q", t.Name)
+	}
+	if _, ok := r.tools[t.Name]; ok {
+		return fmt.Errorf("tool %s is already registered", t.Name)
+	}
+	if t.Func == nil {
+		return fmt.Errorf("tool %s has no function", t.Name)
+	}
+	if t.InputSchema.Type == "" {
+		t.InputSchema.Type = "object"
+	}
+
+	r.tools[t.Name] = t
+	r.names = append(r.names, t.Name)
+	return nil
+}
+
+// Tools returns the registered tools in registration order.
+func (r *Registry) Tools() []Tool {
+	tools := make([]Tool, 0, len(r.names))
+	for _, name := range r.names {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+func (r *Registry) Tool(name string) (Tool, bool) {
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Call runs the named tool after checking the input has the required fields.
+func (r *Registry) Call(ctx context.Context, name string, input json.RawMessage) (string, error) {
+	t, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %s", name)
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(input, &fields)
+	if err != nil {
+		return "", fmt.Errorf("input of tool %s is not a JSON object: %w", name, err)
+	}
+
+	var missing []string
+	for _, f := range t.InputSchema.Required {
+		if _, ok := fields[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing required input for tool %s: %s", name, strings.Join(missing, ", "))
+	}
+
+	return t.Func(ctx, input)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxGenerations = 5
+
+// RankedGeneration is a generation along with the score it was ranked by.
+type RankedGeneration struct {
+	ResponseGeneration
+	Index                int
+	AverageLogLikelihood float64
+}
+
+// averageLogLikelihood returns the mean token log-likelihood of a generation,
+// so that longer generations are not penalized for having more tokens.
+func averageLogLikelihood(g ResponseGeneration) float64 {
+	if len(g.TokenLikelihoods) == 0 {
+		return math.Inf(-1)
+	}
+
+	var total float64
+	for _, t := range g.TokenLikelihoods {
+		total += t.Likelihood
+	}
+	return total / float64(len(g.TokenLikelihoods))
+}
+
+// rankGenerations orders generations from most to least likely.
+func rankGenerations(generations []ResponseGeneration) []RankedGeneration {
+	ranked := make([]RankedGeneration, 0, len(generations))
+	for i, g := range generations {
+		ranked = append(ranked, RankedGeneration{ResponseGeneration: g, Index: i, AverageLogLikelihood: averageLogLikelihood(g)})
+	}
+
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return ranked[a].AverageLogLikelihood > ranked[b].AverageLogLikelihood
+	})
+	return ranked
+}
+
+// printSideBySide prints the ranked generations in columns that fit within
+// the given terminal width.
+func printSideBySide(ranked []RankedGeneration, width int) {
+	const gutter = " | "
+
+	if len(ranked) == 0 {
+		fmt.Println("no generations to compare")
+		return
+	}
+
+	colWidth := (width - len(gutter)*(len(ranked)-1)) / len(ranked)
+	if colWidth < 10 {
+		colWidth = 10
+	}
+
+	columns := make([][]string, len(ranked))
+	var rows int
+	for i, r := range ranked {
+		header := fmt.Sprintf("#%d (generation %d)", i+1, r.Index+1)
+		score := fmt.Sprintf("avg log-likelihood %.4f", r.AverageLogLikelihood)
+		finish := fmt.Sprintf("finish reason %s", r.FinishReason)
+
+		columns[i] = append([]string{header, score, finish, strings.Repeat("-", colWidth)}, wrap(r.Text, colWidth)...)
+		if len(columns[i]) > rows {
+			rows = len(columns[i])
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			var cell string
+			if row < len(col) {
+				cell = col[row]
+			}
+			cells[i] = fmt.Sprintf("%-*s", colWidth, truncate(cell, colWidth))
+		}
+		fmt.Println(strings.TrimRight(strings.Join(cells, gutter), " "))
+	}
+}
+
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate cuts s to at most width runes, so that multi-byte characters are
+// not split.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width])
+}
+
+// probability converts a log-likelihood to a probability between 0 and 1.
+func probability(likelihood float64) float64 {
+	return math.Exp(likelihood)
+}
+
+// heatColor maps a probability to a red (unlikely) to green (likely) color.
+func heatColor(p float64) (r, g, b int) {
+	if p < 0.5 {
+		return 255, int(510 * p), 0
+	}
+	return int(510 * (1 - p)), 255, 0
+}
+
+// printHeatmap writes the tokens with a 24-bit ANSI background color
+// reflecting each token's likelihood.
+func printHeatmap(w io.Writer, tokens []TokenLikelihood) {
+	for _, t := range tokens {
+		r, g, b := heatColor(probability(t.Likelihood))
+		fmt.Fprintf(w, "\x1b[48;2;%d;%d;%dm\x1b[30m%s\x1b[0m", r, g, b, t.Token)
+	}
+	fmt.Fprintln(w)
+}
+
+var heatmapTemplate = template.Must(template.New("heatmap").Funcs(template.FuncMap{
+	"color": func(likelihood float64) template.CSS {
+		r, g, b := heatColor(probability(likelihood))
+		return template.CSS(fmt.Sprintf("background-color: rgb(%d, %d, %d)", r, g, b))
+	},
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Token likelihood heatmap</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.tokens { font-family: monospace; white-space: pre-wrap; line-height: 1.8; }
+</style>
+</head>
+<body>
+{{range $i, $g := .}}
+<h2>Generation {{add $i 1}}</h2>
+<p>finish reason: {{$g.FinishReason}}</p>
+<div class="tokens">{{range $g.TokenLikelihoods}}<span style="{{color .Likelihood}}" title="{{.Likelihood}}">{{.Token}}</span>{{end}}</div>
+{{end}}
+</body>
+</html>
+`))
+
+func writeHTMLHeatmap(file string, generations []ResponseGeneration) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return heatmapTemplate.Execute(f, generations)
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 )
 
 const defaultRegion = "us-east-1"
@@ -27,6 +30,33 @@ const prompt = `Turn the following product feature into a list of benefits. Then
 Our app automatically transcribes your meetings. It uses state-of-the-art speech-to-text technology that works even in noisy backgrounds. Once the transcription is done, our app creates its summary and automatically emails it to the meeting attendees."`
 
 func main() {
+	stream := flag.Bool("stream", false, "stream the response as it is generated")
+	generations := flag.Int("generations", 1, "number of generations to request (1 to 5)")
+	likelihoods := flag.String("likelihoods", string(None), "token likelihoods to return - GENERATION, ALL or NONE")
+	rank := flag.Bool("rank", false, "rank the generations by average log-likelihood and print them side by side")
+	heatmap := flag.String("heatmap", "", "render a token likelihood heatmap - terminal or html")
+	heatmapFile := flag.String("heatmap-file", "heatmap.html", "file to write the html heatmap to")
+	width := flag.Int("width", 160, "terminal width used to print generations side by side")
+	flag.Parse()
+
+	if *generations < 1 || *generations > maxGenerations {
+		log.Fatalf("generations must be between 1 and %d", maxGenerations)
+	}
+
+	returnLikelihoods := ReturnLikelihood(*likelihoods)
+	switch returnLikelihoods {
+	case Generation, All, None:
+	default:
+		log.Fatal("invalid likelihoods option: ", *likelihoods)
+	}
+
+	if *stream && (*rank || *heatmap != "") {
+		log.Fatal("rank and heatmap are not supported with streaming")
+	}
+
+	if (*rank || *heatmap != "") && returnLikelihoods == None {
+		returnLikelihoods = Generation
+	}
 
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -46,7 +76,51 @@ func main() {
 		P:                 0.75,
 		K:                 0,
 		MaxTokens:         200,
-		ReturnLikelihoods: None,
+		ReturnLikelihoods: returnLikelihoods,
+		NumGenerations:    *generations,
+	}
+
+	if *stream {
+		payload.Stream = true
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		output, err := brc.InvokeModelWithResponseStream(context.Background(), &bedrockruntime.InvokeModelWithResponseStreamInput{
+			Body:        payloadBytes,
+			ModelId:     aws.String(cohereCommandModelID),
+			ContentType: aws.String("application/json"),
+			Accept:      aws.String("*/*"),
+		})
+
+		if err != nil {
+			log.Fatal("failed to invoke model: ", err)
+		}
+
+		fmt.Println("response from LLM")
+
+		// chunks of different generations arrive interleaved, so only the first
+		// one is printed as it streams and the rest are printed once complete
+		resp, err := processStreamingOutput(output, func(ctx context.Context, index int, part []byte) error {
+			if index == 0 {
+				fmt.Print(string(part))
+			}
+			return nil
+		})
+
+		if err != nil {
+			log.Fatal("streaming output processing error: ", err)
+		}
+
+		for i, g := range resp.Generations {
+			if i > 0 {
+				fmt.Printf("\n\n--- generation %d ---\n%s", i+1, g.Text)
+			}
+			fmt.Println("\n\nfinish reason -", g.FinishReason)
+		}
+		return
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -75,8 +149,104 @@ func main() {
 		log.Fatal("failed to unmarshal", err)
 	}
 
-	fmt.Println("response from LLM\n", resp.Generations[0].Text)
+	if *rank {
+		ranked := rankGenerations(resp.Generations)
+		printSideBySide(ranked, *width)
+	} else {
+		for _, g := range resp.Generations {
+			fmt.Println("response from LLM\n", g.Text)
+		}
+	}
+
+	switch *heatmap {
+	case "":
+	case "terminal":
+		for i, g := range resp.Generations {
+			fmt.Printf("\n--- generation %d ---\n", i+1)
+			printHeatmap(os.Stdout, g.TokenLikelihoods)
+		}
+	case "html":
+		err = writeHTMLHeatmap(*heatmapFile, resp.Generations)
+		if err != nil {
+			log.Fatal("failed to write heatmap: ", err)
+		}
+		fmt.Println("\nheatmap written to file", *heatmapFile)
+	default:
+		log.Fatal("invalid heatmap option: ", *heatmap)
+	}
+
+}
+
+// StreamingOutputHandler receives each piece of generated text as it arrives,
+// along with the index of the generation it belongs to (always 0 unless
+// NumGenerations is more than 1).
+type StreamingOutputHandler func(ctx context.Context, index int, part []byte) error
+
+func processStreamingOutput(output *bedrockruntime.InvokeModelWithResponseStreamOutput, handler StreamingOutputHandler) (Response, error) {
+
+	resp := Response{}
+	generations := map[int]*ResponseGeneration{}
+
+	generation := func(index int) *ResponseGeneration {
+		if _, ok := generations[index]; !ok {
+			generations[index] = &ResponseGeneration{}
+		}
+		return generations[index]
+	}
+
+	for event := range output.GetStream().Events() {
+		switch v := event.(type) {
+		case *types.ResponseStreamMemberChunk:
+
+			//fmt.Println("payload", string(v.Value.Bytes))
+
+			var chunk StreamChunk
+			err := json.NewDecoder(bytes.NewReader(v.Value.Bytes)).Decode(&chunk)
+			if err != nil {
+				return resp, err
+			}
+
+			if chunk.IsFinished {
+				generation(chunk.Index).FinishReason = chunk.FinishReason
+				if chunk.Response != nil {
+					resp.ID = chunk.Response.ID
+					resp.Prompt = chunk.Response.Prompt
+				}
+				continue
+			}
+
+			err = handler(context.Background(), chunk.Index, []byte(chunk.Text))
+			if err != nil {
+				return resp, err
+			}
+			generation(chunk.Index).Text += chunk.Text
+
+		case *types.UnknownUnionMember:
+			fmt.Println("unknown tag:", v.Tag)
+
+		default:
+			fmt.Println("union is nil or unknown type")
+		}
+	}
 
+	err := output.GetStream().Close()
+	if err != nil {
+		return resp, err
+	}
+
+	if err := output.GetStream().Err(); err != nil {
+		return resp, err
+	}
+
+	for i := 0; i < len(generations); i++ {
+		g, ok := generations[i]
+		if !ok {
+			return resp, fmt.Errorf("missing generation %d in streaming output", i)
+		}
+		resp.Generations = append(resp.Generations, *g)
+	}
+
+	return resp, nil
 }
 
 //request//response model
@@ -102,8 +272,19 @@ const (
 )
 
 type ResponseGeneration struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID               string            `json:"id"`
+	Text             string            `json:"text"`
+	FinishReason     string            `json:"finish_reason,omitempty"`
+	Likelihood       float64           `json:"likelihood,omitempty"`
+	TokenLikelihoods []TokenLikelihood `json:"token_likelihoods,omitempty"`
+}
+
+// TokenLikelihood is the log-likelihood of a single token. It is only returned
+// when ReturnLikelihoods is GENERATION (generated tokens) or ALL (prompt and
+// generated tokens).
+type TokenLikelihood struct {
+	Token      string  `json:"token"`
+	Likelihood float64 `json:"likelihood"`
 }
 
 type Response struct {
@@ -111,3 +292,13 @@ type Response struct {
 	ID          string               `json:"id"`
 	Prompt      string               `json:"prompt"`
 }
+
+// StreamChunk is a single event of a streaming response. The final chunk of a
+// generation has IsFinished set, along with the finish reason.
+type StreamChunk struct {
+	Text         string    `json:"text"`
+	Index        int       `json:"index"`
+	IsFinished   bool      `json:"is_finished"`
+	FinishReason string    `json:"finish_reason,omitempty"`
+	Response     *Response `json:"response,omitempty"`
+}
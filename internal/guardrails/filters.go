@@ -0,0 +1,162 @@
+package guardrails
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Filter is a local content check. Filters run before the prompt is sent
+// (pre) and on the model output (post), whether or not a Bedrock guardrail
+// is configured.
+type Filter interface {
+	// Check returns a non-nil error if the text is not allowed.
+	Check(ctx context.Context, text string) error
+}
+
+// Filters holds the pre and post filters applied to a conversation.
+type Filters struct {
+	Pre  []Filter
+	Post []Filter
+}
+
+func (f Filters) CheckInput(ctx context.Context, text string) error {
+	return runFilters(ctx, "input", f.Pre, text)
+}
+
+func (f Filters) CheckOutput(ctx context.Context, text string) error {
+	return runFilters(ctx, "output", f.Post, text)
+}
+
+func runFilters(ctx context.Context, stage string, filters []Filter, text string) error {
+	for _, f := range filters {
+		err := f.Check(ctx, text)
+		if err != nil {
+			return &FilterError{Stage: stage, Err: err}
+		}
+	}
+	return nil
+}
+
+// FilterError is returned when a local filter rejects the input or output.
+type FilterError struct {
+	Stage string
+	Err   error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s blocked by local filter: %v", e.Stage, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// DenyList rejects text containing any of its terms (case-insensitive, whole
+// words) or matching any of its patterns.
+type DenyList struct {
+	patterns []*regexp.Regexp
+}
+
+// LoadDenyList reads one term per line. Lines starting with "re:" are regular
+// expressions, and empty lines or lines starting with # are ignored.
+func LoadDenyList(file string) (*DenyList, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := &DenyList{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		expr := strings.TrimPrefix(line, "re:")
+		if expr == line {
+			expr = termPattern(line)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny list entry %q: %w", line, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+
+	return d, scanner.Err()
+}
+
+// termPattern matches the term as a whole word, ignoring case. A word boundary
+// is only required next to word characters, so that terms such as "c++",
+// ".NET" or "@admin" can match.
+func termPattern(term string) string {
+	expr := regexp.QuoteMeta(term)
+	if isWordChar(term[0]) {
+		expr = `\b` + expr
+	}
+	if isWordChar(term[len(term)-1]) {
+		expr += `\b`
+	}
+	return `(?i)` + expr
+}
+
+// isWordChar reports whether c is matched by \w, which is ASCII only.
+func isWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (d *DenyList) Check(ctx context.Context, text string) error {
+	var matches []string
+	for _, re := range d.patterns {
+		if m := re.FindString(text); m != "" {
+			matches = append(matches, fmt.Sprintf("%q", m))
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	sort.Strings(matches)
+	return fmt.Errorf("matched deny list: %s", strings.Join(matches, ", "))
+}
+
+// FilterFlags registers the flags for the local deny lists.
+func FilterFlags(fs *flag.FlagSet) (pre, post *string) {
+	pre = fs.String("deny-input", "", "deny list file checked against every message before it is sent")
+	post = fs.String("deny-output", "", "deny list file checked against every model response")
+	return pre, post
+}
+
+// LoadFilters loads the deny lists named by the FilterFlags flags.
+func LoadFilters(pre, post string) (Filters, error) {
+	var filters Filters
+
+	if pre != "" {
+		d, err := LoadDenyList(pre)
+		if err != nil {
+			return filters, err
+		}
+		filters.Pre = append(filters.Pre, d)
+	}
+
+	if post != "" {
+		d, err := LoadDenyList(post)
+		if err != nil {
+			return filters, err
+		}
+		filters.Post = append(filters.Post, d)
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,180 @@
+// Package guardrails applies Amazon Bedrock guardrails to model invocations,
+// decodes the guardrail results in model responses, and provides local
+// deny-list filters for the input and output of a conversation.
+package guardrails
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// Config holds the Bedrock guardrail to apply to model invocations.
+// Bedrock guardrails are only applied if an identifier is set.
+type Config struct {
+	Identifier string
+	Version    string
+	Trace      bool
+}
+
+// Flags registers the guardrail flags on fs.
+func Flags(fs *flag.FlagSet) *Config {
+	c := &Config{}
+	fs.StringVar(&c.Identifier, "guardrail", "", "ID or ARN of the Bedrock guardrail to apply")
+	fs.StringVar(&c.Version, "guardrail-version", "DRAFT", "version of the guardrail - DRAFT or a version number")
+	fs.BoolVar(&c.Trace, "guardrail-trace", false, "include the guardrail trace in the response")
+	return c
+}
+
+func (c *Config) Enabled() bool {
+	return c != nil && c.Identifier != ""
+}
+
+// Apply returns the guardrail input parameters shared by InvokeModel and
+// InvokeModelWithResponseStream.
+func (c *Config) Apply() (identifier, version *string, trace types.Trace) {
+	if !c.Enabled() {
+		return nil, nil, ""
+	}
+
+	trace = types.TraceDisabled
+	if c.Trace {
+		trace = types.TraceEnabled
+	}
+
+	return aws.String(c.Identifier), aws.String(c.Version), trace
+}
+
+const ActionIntervened = "INTERVENED"
+
+// Result is decoded from the guardrail fields Bedrock adds to the
+// model response body when a guardrail is applied.
+type Result struct {
+	Action string `json:"amazon-bedrock-guardrailAction,omitempty"`
+	Trace  *Trace `json:"amazon-bedrock-trace,omitempty"`
+}
+
+func (r Result) Intervened() bool {
+	return r.Action == ActionIntervened
+}
+
+type Trace struct {
+	Guardrail struct {
+		// Input assessments are keyed by guardrail ID.
+		Input   map[string]Assessment   `json:"input,omitempty"`
+		Outputs []map[string]Assessment `json:"outputs,omitempty"`
+	} `json:"guardrail"`
+}
+
+type Assessment struct {
+	TopicPolicy *struct {
+		Topics []struct {
+			Name   string `json:"name"`
+			Type   string `json:"type"`
+			Action string `json:"action"`
+		} `json:"topics"`
+	} `json:"topicPolicy,omitempty"`
+	ContentPolicy *struct {
+		Filters []struct {
+			Type       string `json:"type"`
+			Confidence string `json:"confidence"`
+			Action     string `json:"action"`
+		} `json:"filters"`
+	} `json:"contentPolicy,omitempty"`
+	WordPolicy *struct {
+		CustomWords []struct {
+			Match  string `json:"match"`
+			Action string `json:"action"`
+		} `json:"customWords"`
+		ManagedWordLists []struct {
+			Match  string `json:"match"`
+			Type   string `json:"type"`
+			Action string `json:"action"`
+		} `json:"managedWordLists"`
+	} `json:"wordPolicy,omitempty"`
+	SensitiveInformationPolicy *struct {
+		PIIEntities []struct {
+			Type   string `json:"type"`
+			Match  string `json:"match"`
+			Action string `json:"action"`
+		} `json:"piiEntities"`
+		Regexes []struct {
+			Name   string `json:"name"`
+			Match  string `json:"match"`
+			Action string `json:"action"`
+		} `json:"regexes"`
+	} `json:"sensitiveInformationPolicy,omitempty"`
+}
+
+// Findings returns a short description of every policy that matched.
+func (a Assessment) Findings() []string {
+	var findings []string
+
+	if a.TopicPolicy != nil {
+		for _, t := range a.TopicPolicy.Topics {
+			findings = append(findings, fmt.Sprintf("topic %s (%s)", t.Name, t.Action))
+		}
+	}
+	if a.ContentPolicy != nil {
+		for _, f := range a.ContentPolicy.Filters {
+			findings = append(findings, fmt.Sprintf("content %s, confidence %s (%s)", f.Type, f.Confidence, f.Action))
+		}
+	}
+	if a.WordPolicy != nil {
+		for _, w := range a.WordPolicy.CustomWords {
+			findings = append(findings, fmt.Sprintf("word %q (%s)", w.Match, w.Action))
+		}
+		for _, w := range a.WordPolicy.ManagedWordLists {
+			findings = append(findings, fmt.Sprintf("%s word %q (%s)", w.Type, w.Match, w.Action))
+		}
+	}
+	if a.SensitiveInformationPolicy != nil {
+		for _, p := range a.SensitiveInformationPolicy.PIIEntities {
+			findings = append(findings, fmt.Sprintf("PII %s (%s)", p.Type, p.Action))
+		}
+		for _, r := range a.SensitiveInformationPolicy.Regexes {
+			findings = append(findings, fmt.Sprintf("regex %s (%s)", r.Name, r.Action))
+		}
+	}
+
+	return findings
+}
+
+// Findings returns the input and output findings of the trace.
+func (t *Trace) Findings() (input, output []string) {
+	if t == nil {
+		return nil, nil
+	}
+
+	for _, a := range t.Guardrail.Input {
+		input = append(input, a.Findings()...)
+	}
+	for _, o := range t.Guardrail.Outputs {
+		for _, a := range o {
+			output = append(output, a.Findings()...)
+		}
+	}
+
+	return input, output
+}
+
+func (r Result) String() string {
+	if !r.Intervened() {
+		return "guardrail did not intervene"
+	}
+
+	s := "guardrail intervened"
+
+	input, output := r.Trace.Findings()
+	if len(input) > 0 {
+		s += "\n  input: " + strings.Join(input, ", ")
+	}
+	if len(output) > 0 {
+		s += "\n  output: " + strings.Join(output, ", ")
+	}
+
+	return s
+}
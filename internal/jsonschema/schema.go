@@ -0,0 +1,166 @@
+// Package jsonschema validates decoded JSON values against the subset of JSON
+// Schema used by the extraction and evaluation examples.
+package jsonschema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema limited to types, properties, required fields,
+// array items, enums, string patterns and minItems. Values are validated with
+// the Validator returned by Compile.
+type Schema struct {
+	Type        string             `json:"type" yaml:"type"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Pattern     string             `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+}
+
+// Validator validates decoded JSON values against a compiled schema.
+type Validator struct {
+	schema   *Schema
+	patterns map[*Schema]*regexp.Regexp
+}
+
+// Compile compiles the patterns of the schema and its sub-schemas, and
+// returns an error naming the first invalid one. The schema must not be
+// modified afterwards.
+func (s *Schema) Compile() (*Validator, error) {
+	v := &Validator{schema: s, patterns: map[*Schema]*regexp.Regexp{}}
+
+	err := v.compile(s, "$")
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *Validator) compile(s *Schema, path string) error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", path, err)
+		}
+		v.patterns[s] = re
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := v.compile(s.Properties[name], path+"."+name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		return v.compile(s.Items, path+"[]")
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON value against the schema. Errors name the
+// offending field but not its value, so they can be sent back to a model
+// without leaking the data.
+func (v *Validator) Validate(path string, value interface{}) []string {
+	return v.validate(v.schema, path, value)
+}
+
+func (v *Validator) validate(s *Schema, path string, value interface{}) []string {
+	if value == nil {
+		return []string{fmt.Sprintf("%s: must not be null", path)}
+	}
+
+	var errs []string
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object", path)}
+		}
+
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required field %q", path, name))
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p, ok := s.Properties[name]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: unexpected field %q", path, name))
+				continue
+			}
+			errs = append(errs, v.validate(p, path+"."+name, obj[name])...)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array", path)}
+		}
+
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			errs = append(errs, fmt.Sprintf("%s: expected at least %d items, got %d", path, *s.MinItems, len(items)))
+		}
+
+		if s.Items != nil {
+			for i, item := range items {
+				errs = append(errs, v.validate(s.Items, fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a %s", path, s.Type)}
+		}
+		if s.Type == "integer" && n != float64(int64(n)) {
+			errs = append(errs, fmt.Sprintf("%s: expected an integer", path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: expected a boolean", path)}
+		}
+	default:
+		str, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a string", path)}
+		}
+
+		if re := v.patterns[s]; re != nil && !re.MatchString(str) {
+			errs = append(errs, fmt.Sprintf("%s: does not match pattern %s", path, s.Pattern))
+		}
+
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			errs = append(errs, fmt.Sprintf("%s: must be one of %s", path, strings.Join(s.Enum, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
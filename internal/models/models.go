@@ -0,0 +1,82 @@
+// Package models maps Bedrock model IDs to the request and response body
+// format they use.
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+)
+
+// Family identifies the request/response body format of a model.
+type Family string
+
+const (
+	// Claude is the text completions format of Claude v1 and v2, with the
+	// prompt in Human/Assistant turns.
+	Claude        Family = "claude"
+	CohereCommand Family = "cohere-command"
+	TitanText     Family = "titan-text"
+)
+
+// families maps model ID prefixes to their format, most specific first.
+var families = []struct {
+	prefix string
+	family Family
+}{
+	{"anthropic.claude-3", ""},
+	{"anthropic.claude", Claude},
+	{"cohere.command", CohereCommand},
+	{"amazon.titan-text", TitanText},
+}
+
+// FamilyOf returns the body format of a foundation model. Claude 3 models are
+// rejected, since they only accept Messages API bodies.
+func FamilyOf(modelID string) (Family, error) {
+	for _, f := range families {
+		if !strings.HasPrefix(modelID, f.prefix) {
+			continue
+		}
+		if f.family == "" {
+			return "", fmt.Errorf("unsupported model %s - Claude 3 models require the Messages API, which is not supported here", modelID)
+		}
+		return f.family, nil
+	}
+	return "", fmt.Errorf("unsupported model %s - supported models are Claude (v1, v2, Instant), Cohere Command and Titan Text", modelID)
+}
+
+// IsProvisionedModelARN reports whether modelID is the ARN of provisioned
+// throughput rather than a model ID.
+func IsProvisionedModelARN(modelID string) bool {
+	return strings.HasPrefix(modelID, "arn:") && strings.Contains(modelID, ":provisioned-model/")
+}
+
+// Resolve returns the body format of the model to invoke. A provisioned model
+// ARN is resolved to the foundation model it (or its custom model) was
+// created from.
+func Resolve(ctx context.Context, bc *bedrock.Client, modelID string) (Family, error) {
+
+	if !IsProvisionedModelARN(modelID) {
+		return FamilyOf(modelID)
+	}
+
+	pm, err := bc.GetProvisionedModelThroughput(ctx, &bedrock.GetProvisionedModelThroughputInput{
+		ProvisionedModelId: aws.String(modelID),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get provisioned model %s: %w", modelID, err)
+	}
+
+	//arn:aws:bedrock:<region>::foundation-model/<model id>
+	foundationModelArn := aws.ToString(pm.FoundationModelArn)
+	family, err := FamilyOf(foundationModelArn[strings.LastIndex(foundationModelArn, "/")+1:])
+	if err != nil {
+		return "", fmt.Errorf("provisioned model %s: %w", modelID, err)
+	}
+
+	return family, nil
+}
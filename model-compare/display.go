@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const columnSeparator = " | "
+
+// wrap breaks text into lines of at most width runes, at spaces where
+// possible.
+func wrap(text string, width int) []string {
+	var lines []string
+
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+
+		for _, word := range strings.Fields(para) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				r := []rune(word)
+				lines = append(lines, string(r[:width]))
+				word = string(r[width:])
+			}
+
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	//drop trailing empty lines
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// pad pads or truncates s to width runes.
+func pad(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		return string([]rune(s)[:width])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// columnWidth returns the width of each of n columns within the total width.
+func columnWidth(total, n int) int {
+	w := (total - (n-1)*len(columnSeparator)) / n
+	if w < 10 {
+		w = 10
+	}
+	return w
+}
+
+// printRow prints one cell per column.
+func printRow(w io.Writer, cells []string, width int) {
+	padded := make([]string, len(cells))
+	for i, c := range cells {
+		padded[i] = pad(c, width)
+	}
+	fmt.Fprintln(w, strings.TrimRight(strings.Join(padded, columnSeparator), " "))
+}
+
+// printColumns prints the text of each column side by side, under a header.
+func printColumns(w io.Writer, headers, texts []string, width int) {
+	printRow(w, headers, width)
+
+	rule := make([]string, len(headers))
+	for i := range rule {
+		rule[i] = strings.Repeat("-", width)
+	}
+	printRow(w, rule, width)
+
+	wrapped := make([][]string, len(texts))
+	rows := 0
+	for i, t := range texts {
+		wrapped[i] = wrap(t, width)
+		if len(wrapped[i]) > rows {
+			rows = len(wrapped[i])
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		cells := make([]string, len(texts))
+		for i, lines := range wrapped {
+			if row < len(lines) {
+				cells[i] = lines[row]
+			}
+		}
+		printRow(w, cells, width)
+	}
+}
+
+// printMetrics prints the invocation metrics below the outputs.
+func printMetrics(w io.Writer, results []Result, width int, streamed bool) {
+	rule := make([]string, len(results))
+	for i := range rule {
+		rule[i] = strings.Repeat("-", width)
+	}
+	printRow(w, rule, width)
+
+	metric := func(f func(r Result) string) {
+		cells := make([]string, len(results))
+		for i, r := range results {
+			cells[i] = f(r)
+		}
+		printRow(w, cells, width)
+	}
+
+	metric(func(r Result) string {
+		return "latency: " + r.Latency.Round(time.Millisecond).String()
+	})
+
+	if streamed {
+		metric(func(r Result) string {
+			return "first token: " + r.FirstToken.Round(time.Millisecond).String()
+		})
+	}
+
+	metric(func(r Result) string {
+		return fmt.Sprintf("tokens: %d in, %d out", r.InputTokens, r.OutputTokens)
+	})
+
+	metric(func(r Result) string {
+		cost, ok := r.Cost()
+		if !ok {
+			return "est. cost: unknown"
+		}
+		return fmt.Sprintf("est. cost: $%.5f", cost)
+	})
+}
+
+// clearScreen moves the cursor to the top left and clears the terminal.
+func clearScreen(w io.Writer) {
+	fmt.Fprint(w, "\033[H\033[2J")
+}
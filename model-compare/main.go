@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const defaultRegion = "us-east-1"
+
+const defaultModels = "anthropic.claude-v2,anthropic.claude-instant-v1,cohere.command-text-v14,amazon.titan-text-express-v1"
+
+const prompt = `Explain to a new developer, in one short paragraph, what an embedding is and why it is useful for search.`
+
+var brc *bedrockruntime.Client
+
+func init() {
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	brc = bedrockruntime.NewFromConfig(cfg)
+}
+
+func main() {
+	modelIDs := flag.String("models", defaultModels, "comma separated IDs of the models to compare")
+	text := flag.String("prompt", prompt, "prompt to send to every model")
+	maxTokens := flag.Int("max-tokens", 512, "maximum number of tokens to generate")
+	temperature := flag.Float64("temperature", 0.5, "temperature")
+	topP := flag.Float64("top-p", 0, "top p (model default if not set)")
+	stream := flag.Bool("stream", false, "stream all outputs into the columns as they are generated")
+	width := flag.Int("width", 0, "terminal width (defaults to $COLUMNS, or 160)")
+	flag.Parse()
+
+	var ids []string
+	for _, id := range strings.Split(*modelIDs, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+
+		_, err := models.FamilyOf(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		log.Fatal("no models to compare")
+	}
+
+	if *width == 0 {
+		*width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+		if *width == 0 {
+			*width = 160
+		}
+	}
+
+	colWidth := columnWidth(*width, len(ids))
+	params := Params{MaxTokens: *maxTokens, Temperature: temperature}
+
+	//explicit values are sent even if zero, unset ones are left to the model
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "top-p" {
+			params.TopP = topP
+		}
+	})
+
+	var results []Result
+	if *stream {
+		results = compareStreaming(ids, *text, params, colWidth)
+	} else {
+		results = compare(ids, *text, params)
+	}
+
+	outputs := make([]string, len(results))
+	for i, r := range results {
+		outputs[i] = strings.TrimSpace(r.Output)
+		if r.Err != nil {
+			outputs[i] = "ERROR: " + r.Err.Error()
+		}
+	}
+
+	if *stream {
+		clearScreen(os.Stdout)
+	}
+
+	printColumns(os.Stdout, ids, outputs, colWidth)
+	printMetrics(os.Stdout, results, colWidth, *stream)
+}
+
+// compare invokes all models concurrently and waits for every result.
+func compare(ids []string, prompt string, p Params) []Result {
+	results := make([]Result, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			results[i] = invoke(context.Background(), id, prompt, p)
+		}(i, id)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// compareStreaming streams all models concurrently, redrawing the columns as
+// output arrives.
+func compareStreaming(ids []string, prompt string, p Params, colWidth int) []Result {
+	results := make([]Result, len(ids))
+	outputs := make([]string, len(ids))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			results[i] = stream(context.Background(), id, prompt, p, func(part string) {
+				mu.Lock()
+				outputs[i] += part
+				mu.Unlock()
+			})
+		}(i, id)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	//redraw at a fixed rate rather than on every token
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return results
+		case <-ticker.C:
+			mu.Lock()
+			snapshot := append([]string(nil), outputs...)
+			mu.Unlock()
+
+			clearScreen(os.Stdout)
+			printColumns(os.Stdout, ids, snapshot, colWidth)
+		}
+	}
+}
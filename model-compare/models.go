@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+
+// Price is the on-demand price in USD per 1000 tokens.
+type Price struct {
+	Input, Output float64
+}
+
+// prices are the us-east-1 on-demand prices at the time of writing, used for
+// cost estimates only - see https://aws.amazon.com/bedrock/pricing/
+var prices = map[string]Price{
+	"anthropic.claude-v2":           {Input: 0.008, Output: 0.024},
+	"anthropic.claude-v2:1":         {Input: 0.008, Output: 0.024},
+	"anthropic.claude-instant-v1":   {Input: 0.0008, Output: 0.0024},
+	"cohere.command-text-v14":       {Input: 0.0015, Output: 0.002},
+	"cohere.command-light-text-v14": {Input: 0.0003, Output: 0.0006},
+	"amazon.titan-text-express-v1":  {Input: 0.0008, Output: 0.0016},
+	"amazon.titan-text-lite-v1":     {Input: 0.0003, Output: 0.0004},
+}
+
+// Params are the inference parameters common to all model families. Unset
+// sampling parameters are left to the model defaults.
+type Params struct {
+	MaxTokens   int
+	Temperature *float64
+	TopP        *float64
+}
+
+// Result is the output of a model and the metrics of the invocation.
+type Result struct {
+	ModelID      string
+	Output       string
+	Latency      time.Duration
+	FirstToken   time.Duration
+	InputTokens  int
+	OutputTokens int
+	Err          error
+}
+
+// Cost returns the estimated cost of the invocation, and false if the price
+// of the model is unknown.
+func (r Result) Cost() (float64, bool) {
+	p, ok := prices[r.ModelID]
+	if !ok {
+		return 0, false
+	}
+	return (float64(r.InputTokens)*p.Input + float64(r.OutputTokens)*p.Output) / 1000, true
+}
+
+// request translates the prompt and parameters into the body format of the
+// model family.
+func request(family models.Family, prompt string, p Params, stream bool) interface{} {
+	switch family {
+	case models.CohereCommand:
+		return CohereRequest{Prompt: prompt, MaxTokens: p.MaxTokens, Temperature: p.Temperature, P: p.TopP, Stream: stream}
+	case models.TitanText:
+		return TitanTextRequest{
+			InputText:            prompt,
+			TextGenerationConfig: TextGenerationConfig{MaxTokenCount: p.MaxTokens, Temperature: p.Temperature, TopP: p.TopP},
+		}
+	default:
+		return ClaudeRequest{Prompt: fmt.Sprintf(claudePromptFormat, prompt), MaxTokensToSample: p.MaxTokens, Temperature: p.Temperature, TopP: p.TopP}
+	}
+}
+
+func invoke(ctx context.Context, modelID, prompt string, p Params) Result {
+	r := Result{ModelID: modelID}
+
+	family, err := models.FamilyOf(modelID)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	payloadBytes, err := json.Marshal(request(family, prompt, p, false))
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	start := time.Now()
+
+	output, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	r.Latency = time.Since(start)
+
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	//token counts are returned as response headers for all model families
+	if raw, ok := awsmiddleware.GetRawResponse(output.ResultMetadata).(*smithyhttp.Response); ok {
+		r.InputTokens = headerInt(raw.Header, "X-Amzn-Bedrock-Input-Token-Count")
+		r.OutputTokens = headerInt(raw.Header, "X-Amzn-Bedrock-Output-Token-Count")
+	}
+
+	r.Output, r.Err = completion(family, output.Body)
+
+	return r
+}
+
+func headerInt(h http.Header, key string) int {
+	n, _ := strconv.Atoi(h.Get(key))
+	return n
+}
+
+func completion(family models.Family, body []byte) (string, error) {
+	switch family {
+	case models.CohereCommand:
+		var resp CohereResponse
+		err := json.Unmarshal(body, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Generations) == 0 {
+			return "", fmt.Errorf("no generations in the response")
+		}
+		return resp.Generations[0].Text, nil
+	case models.TitanText:
+		var resp TitanTextResponse
+		err := json.Unmarshal(body, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Results) == 0 {
+			return "", fmt.Errorf("no results in the response")
+		}
+		return resp.Results[0].OutputText, nil
+	default:
+		var resp ClaudeResponse
+		err := json.Unmarshal(body, &resp)
+		return resp.Completion, err
+	}
+}
+
+// stream invokes the model with a streaming response, calling onPart with
+// each part of the output as it arrives.
+func stream(ctx context.Context, modelID, prompt string, p Params, onPart func(part string)) Result {
+	r := Result{ModelID: modelID}
+
+	family, err := models.FamilyOf(modelID)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	payloadBytes, err := json.Marshal(request(family, prompt, p, true))
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	start := time.Now()
+
+	output, err := brc.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		r.Err = err
+		r.Latency = time.Since(start)
+		return r
+	}
+
+	var combined strings.Builder
+
+	for event := range output.GetStream().Events() {
+		v, ok := event.(*types.ResponseStreamMemberChunk)
+		if !ok {
+			continue
+		}
+
+		var chunk StreamChunk
+		err := json.NewDecoder(bytes.NewReader(v.Value.Bytes)).Decode(&chunk)
+		if err != nil {
+			r.Err = err
+			break
+		}
+
+		//the last chunk carries the invocation metrics for all model families
+		if m := chunk.Metrics; m != nil {
+			r.InputTokens = m.InputTokenCount
+			r.OutputTokens = m.OutputTokenCount
+		}
+
+		part := chunk.Completion + chunk.Text + chunk.OutputText
+		if part == "" {
+			continue
+		}
+
+		if r.FirstToken == 0 {
+			r.FirstToken = time.Since(start)
+		}
+
+		combined.WriteString(part)
+		onPart(part)
+	}
+
+	if r.Err == nil {
+		r.Err = output.GetStream().Err()
+	}
+
+	r.Latency = time.Since(start)
+	r.Output = combined.String()
+
+	return r
+}
+
+// StreamChunk holds the fields of the streamed chunks of all model families:
+// Claude sends completion, Cohere text and Titan outputText.
+type StreamChunk struct {
+	Completion string             `json:"completion"`
+	Text       string             `json:"text"`
+	OutputText string             `json:"outputText"`
+	Metrics    *InvocationMetrics `json:"amazon-bedrock-invocationMetrics,omitempty"`
+}
+
+type InvocationMetrics struct {
+	InputTokenCount   int `json:"inputTokenCount"`
+	OutputTokenCount  int `json:"outputTokenCount"`
+	InvocationLatency int `json:"invocationLatency"`
+	FirstByteLatency  int `json:"firstByteLatency"`
+}
+
+//request/response model (Claude)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+}
+
+//request/response model (Cohere)
+
+type CohereRequest struct {
+	Prompt        string   `json:"prompt"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	P             *float64 `json:"p,omitempty"`
+	K             float64  `json:"k,omitempty"`
+	MaxTokens     int      `json:"max_tokens,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	Stream        bool     `json:"stream,omitempty"`
+}
+
+type CohereResponse struct {
+	Generations []struct {
+		Text string `json:"text"`
+	} `json:"generations"`
+}
+
+//request/response model (Titan Text)
+
+type TitanTextRequest struct {
+	InputText            string               `json:"inputText"`
+	TextGenerationConfig TextGenerationConfig `json:"textGenerationConfig"`
+}
+
+type TextGenerationConfig struct {
+	MaxTokenCount int      `json:"maxTokenCount,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type TitanTextResponse struct {
+	InputTextTokenCount int `json:"inputTextTokenCount"`
+	Results             []struct {
+		TokenCount int    `json:"tokenCount"`
+		OutputText string `json:"outputText"`
+	} `json:"results"`
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var assertionTypes = []string{"contains", "regex", "json-schema", "extract-match", "similarity", "llm-judge"}
+
+const (
+	defaultSimilarityThreshold = 0.8
+	defaultJudgeThreshold      = 7
+)
+
+// AssertionResult is the outcome of an assertion. Score is the similarity or
+// judge score, where the assertion has one.
+type AssertionResult struct {
+	Type    string   `json:"type"`
+	Passed  bool     `json:"passed"`
+	Message string   `json:"message,omitempty"`
+	Score   *float64 `json:"score,omitempty"`
+}
+
+func (a Assertion) validate() error {
+	if !contains(assertionTypes, a.Type) {
+		return fmt.Errorf("unknown assertion type %q - use one of %s", a.Type, strings.Join(assertionTypes, ", "))
+	}
+
+	switch a.Type {
+	case "contains", "similarity":
+		if a.Value == "" {
+			return fmt.Errorf("%s needs a value", a.Type)
+		}
+	case "regex":
+		_, err := regexp.Compile(a.Value)
+		if err != nil {
+			return err
+		}
+	case "json-schema":
+		if a.Schema == nil {
+			return fmt.Errorf("json-schema needs a schema")
+		}
+		_, err := a.Schema.Compile()
+		return err
+	case "extract-match":
+		if a.Pattern != "" {
+			_, err := regexp.Compile(a.Pattern)
+			if err != nil {
+				return err
+			}
+		}
+	case "llm-judge":
+		if a.Rubric == "" {
+			return fmt.Errorf("llm-judge needs a rubric")
+		}
+	}
+
+	return nil
+}
+
+// check runs the assertion against the output generated for the test.
+func (a Assertion) check(ctx context.Context, s *Suite, t Test, output string) AssertionResult {
+	r := AssertionResult{Type: a.Type}
+
+	switch a.Type {
+	case "contains":
+		r.Passed = strings.Contains(output, a.Value)
+		if a.IgnoreCase {
+			r.Passed = strings.Contains(strings.ToLower(output), strings.ToLower(a.Value))
+		}
+		if !r.Passed {
+			r.Message = fmt.Sprintf("output does not contain %q", a.Value)
+		}
+	case "regex":
+		expr := a.Value
+		if a.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		r.Passed = regexp.MustCompile(expr).MatchString(output)
+		if !r.Passed {
+			r.Message = fmt.Sprintf("output does not match %s", a.Value)
+		}
+	case "json-schema":
+		v, err := extractJSON(output)
+		if err != nil {
+			r.Message = err.Error()
+			break
+		}
+		validator, err := a.Schema.Compile()
+		if err != nil {
+			r.Message = err.Error()
+			break
+		}
+		errs := validator.Validate("$", v)
+		r.Passed = len(errs) == 0
+		r.Message = strings.Join(errs, "; ")
+	case "extract-match":
+		r.Passed, r.Message = a.extractMatch(output)
+	case "similarity":
+		r.Passed, r.Score, r.Message = a.similarity(ctx, s.EmbeddingModel, output)
+	case "llm-judge":
+		r.Passed, r.Score, r.Message = a.judge(ctx, s.JudgeModel, t.Prompt, output)
+	}
+
+	return r
+}
+
+// extractMatch compares the items in the output with the expected values.
+func (a Assertion) extractMatch(output string) (bool, string) {
+	var items []string
+
+	if a.Pattern != "" {
+		re := regexp.MustCompile(a.Pattern)
+		for _, m := range re.FindAllStringSubmatch(output, -1) {
+			//use the first group if the pattern has one
+			if len(m) > 1 {
+				items = append(items, m[1])
+			} else {
+				items = append(items, m[0])
+			}
+		}
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, line)
+			}
+		}
+	}
+
+	expected := append([]string(nil), a.Values...)
+	if a.Unordered {
+		sort.Strings(items)
+		sort.Strings(expected)
+	}
+
+	if strings.Join(items, "\n") == strings.Join(expected, "\n") {
+		return true, ""
+	}
+	return false, fmt.Sprintf("extracted %q, expected %q", items, expected)
+}
+
+func (a Assertion) similarity(ctx context.Context, modelID, output string) (bool, *float64, string) {
+	threshold := a.Threshold
+	if threshold == 0 {
+		threshold = defaultSimilarityThreshold
+	}
+
+	got, err := embed(ctx, modelID, output)
+	if err != nil {
+		return false, nil, fmt.Sprintf("failed to embed output: %v", err)
+	}
+
+	want, err := embed(ctx, modelID, a.Value)
+	if err != nil {
+		return false, nil, fmt.Sprintf("failed to embed reference: %v", err)
+	}
+
+	score := cosine(got, want)
+	if score < threshold {
+		return false, &score, fmt.Sprintf("similarity %.3f is below %.3f", score, threshold)
+	}
+	return true, &score, ""
+}
+
+const judgePrompt = `You are grading the output of an AI assistant.
+
+<prompt>
+%s
+</prompt>
+
+<output>
+%s
+</output>
+
+<rubric>
+%s
+</rubric>
+
+Grade how well the output satisfies the rubric on a scale of 0 (not at all) to 10 (completely). Explain your reasoning in <reasoning></reasoning> tags, then output only the score in <score></score> tags.`
+
+var scorePattern = regexp.MustCompile(`<score>\s*(\d+(?:\.\d+)?)\s*</score>`)
+var reasoningPattern = regexp.MustCompile(`(?s)<reasoning>(.*?)</reasoning>`)
+
+func (a Assertion) judge(ctx context.Context, modelID, prompt, output string) (bool, *float64, string) {
+	threshold := a.Threshold
+	if threshold == 0 {
+		threshold = defaultJudgeThreshold
+	}
+
+	grade, err := generate(ctx, modelID, fmt.Sprintf(judgePrompt, prompt, output, a.Rubric), ParamSet{MaxTokens: 1024})
+	if err != nil {
+		return false, nil, fmt.Sprintf("failed to invoke judge: %v", err)
+	}
+
+	m := scorePattern.FindStringSubmatch(grade)
+	if m == nil {
+		return false, nil, "judge did not return a score"
+	}
+
+	score, _ := strconv.ParseFloat(m[1], 64)
+
+	var reasoning string
+	if r := reasoningPattern.FindStringSubmatch(grade); r != nil {
+		reasoning = strings.TrimSpace(r[1])
+	}
+
+	if score < threshold {
+		return false, &score, fmt.Sprintf("score %.1f is below %.1f: %s", score, threshold, reasoning)
+	}
+	return true, &score, reasoning
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const defaultRegion = "us-east-1"
+
+var brc *bedrockruntime.Client
+
+func init() {
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	brc = bedrockruntime.NewFromConfig(cfg)
+}
+
+func main() {
+	suiteFile := flag.String("suite", "suites/extraction.yaml", "YAML or JSON test suite")
+	modelIDs := flag.String("models", "", "comma separated model IDs to run the suite against (overrides the suite models)")
+	jsonReport := flag.String("json", "", "file to write the JSON report to")
+	junitReport := flag.String("junit", "", "file to write the JUnit XML report to")
+	concurrency := flag.Int("concurrency", 4, "number of tests to run at the same time")
+	verbose := flag.Bool("verbose", false, "print the output of failed tests")
+	flag.Parse()
+
+	suite, err := loadSuite(*suiteFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *modelIDs != "" {
+		suite.Models = nil
+		for _, m := range strings.Split(*modelIDs, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				suite.Models = append(suite.Models, m)
+			}
+		}
+	}
+
+	if len(suite.Models) == 0 {
+		log.Fatal("no models to run the suite against")
+	}
+
+	for _, m := range suite.Models {
+		_, err := models.FamilyOf(m)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	started := time.Now()
+	results := run(suite, *concurrency)
+	report := newReport(suite.Name, started, results)
+
+	printSummary(report, *verbose)
+
+	if *jsonReport != "" {
+		err := report.writeJSON(*jsonReport)
+		if err != nil {
+			log.Fatal("failed to write JSON report: ", err)
+		}
+	}
+
+	if *junitReport != "" {
+		err := report.writeJUnit(*junitReport)
+		if err != nil {
+			log.Fatal("failed to write JUnit report: ", err)
+		}
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// run executes every test for every model and parameter set. Results are in
+// model, parameter set, test order regardless of completion order.
+func run(suite *Suite, concurrency int) []Result {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var results []Result
+	for _, m := range suite.Models {
+		for _, p := range suite.Params {
+			for _, t := range suite.Tests {
+				results = append(results, Result{Model: m, Params: p.Name, Test: t.Name})
+			}
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	i := 0
+	for _, m := range suite.Models {
+		for _, p := range suite.Params {
+			for _, t := range suite.Tests {
+				wg.Add(1)
+				sem <- struct{}{}
+
+				go func(r *Result, modelID string, p ParamSet, t Test) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					runTest(context.Background(), suite, r, modelID, p, t)
+				}(&results[i], m, p, t)
+
+				i++
+			}
+		}
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func runTest(ctx context.Context, suite *Suite, r *Result, modelID string, p ParamSet, t Test) {
+
+	start := time.Now()
+	output, err := generate(ctx, modelID, t.Prompt, p)
+	r.LatencyMS = time.Since(start).Milliseconds()
+
+	if err != nil {
+		r.Error = err.Error()
+		return
+	}
+
+	r.Output = strings.TrimSpace(output)
+	r.Passed = true
+
+	for _, a := range t.Assert {
+		res := a.check(ctx, suite, t, r.Output)
+		r.Assertions = append(r.Assertions, res)
+		if !res.Passed {
+			r.Passed = false
+		}
+	}
+}
+
+func printSummary(report Report, verbose bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tPARAMS\tTEST\tRESULT\tLATENCY\tDETAILS")
+
+	for _, r := range report.Results {
+		status, details := "PASS", ""
+		switch {
+		case r.Error != "":
+			status, details = "ERROR", r.Error
+		case !r.Passed:
+			status, details = "FAIL", strings.Join(r.failures(), "; ")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%dms\t%s\n", r.Model, r.Params, r.Test, status, r.LatencyMS, details)
+	}
+	w.Flush()
+
+	if verbose {
+		for _, r := range report.Results {
+			if !r.Passed && r.Output != "" {
+				fmt.Printf("\n--- output of %s (%s/%s) ---\n%s\n", r.Test, r.Model, r.Params, r.Output)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d tests, %d passed, %d failed in %s\n", report.Total, report.Passed, report.Failed, time.Duration(report.DurationMS)*time.Millisecond)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const (
+	claudePromptFormat    = "\n\nHuman:%s\n\nAssistant:"
+	claudeV2ModelID       = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+	titanEmbeddingModelID = "amazon.titan-embed-text-v1"
+)
+
+const defaultMaxTokens = 1024
+
+// generate sends the prompt to the model and returns the generated text.
+func generate(ctx context.Context, modelID, prompt string, p ParamSet) (string, error) {
+
+	family, err := models.FamilyOf(modelID)
+	if err != nil {
+		return "", err
+	}
+
+	maxTokens := p.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	var payload interface{}
+
+	switch family {
+	case models.CohereCommand:
+		payload = CohereRequest{Prompt: prompt, MaxTokens: maxTokens, Temperature: p.Temperature, P: p.TopP, K: p.TopK, StopSequences: p.Stop}
+	case models.TitanText:
+		payload = TitanTextRequest{
+			InputText: prompt,
+			TextGenerationConfig: TextGenerationConfig{
+				MaxTokenCount: maxTokens,
+				Temperature:   p.Temperature,
+				TopP:          p.TopP,
+				StopSequences: p.Stop,
+			},
+		}
+	default:
+		payload = ClaudeRequest{Prompt: fmt.Sprintf(claudePromptFormat, prompt), MaxTokensToSample: maxTokens, Temperature: p.Temperature, TopP: p.TopP, TopK: p.TopK, StopSequences: p.Stop}
+	}
+
+	body, err := invoke(ctx, modelID, payload)
+	if err != nil {
+		return "", err
+	}
+
+	switch family {
+	case models.CohereCommand:
+		var resp CohereResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Generations) == 0 {
+			return "", fmt.Errorf("no generations in the response of %s", modelID)
+		}
+		return resp.Generations[0].Text, nil
+	case models.TitanText:
+		var resp TitanTextResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Results) == 0 {
+			return "", fmt.Errorf("no results in the response of %s", modelID)
+		}
+		return resp.Results[0].OutputText, nil
+	default:
+		var resp ClaudeResponse
+		err = json.Unmarshal(body, &resp)
+		return resp.Completion, err
+	}
+}
+
+// embed returns the embedding of text using a Titan or Cohere embedding model.
+func embed(ctx context.Context, modelID, text string) ([]float32, error) {
+
+	if strings.HasPrefix(modelID, "cohere.embed") {
+		body, err := invoke(ctx, modelID, CohereEmbedRequest{Texts: []string{text}, InputType: "search_document"})
+		if err != nil {
+			return nil, err
+		}
+
+		var resp CohereEmbedResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Embeddings) == 0 {
+			return nil, fmt.Errorf("no embeddings in the response of %s", modelID)
+		}
+		return resp.Embeddings[0], nil
+	}
+
+	body, err := invoke(ctx, modelID, TitanEmbeddingRequest{InputText: text})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TitanEmbeddingResponse
+	err = json.Unmarshal(body, &resp)
+	return resp.Embedding, err
+}
+
+func invoke(ctx context.Context, modelID string, payload interface{}) ([]byte, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Body, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+//request/response model (Claude)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              *int     `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+}
+
+//request/response model (Cohere)
+
+type CohereRequest struct {
+	Prompt        string   `json:"prompt"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	P             *float64 `json:"p,omitempty"`
+	K             *int     `json:"k,omitempty"`
+	MaxTokens     int      `json:"max_tokens,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+type CohereResponse struct {
+	Generations []struct {
+		Text string `json:"text"`
+	} `json:"generations"`
+}
+
+type CohereEmbedRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+}
+
+type CohereEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+//request/response model (Titan)
+
+type TitanTextRequest struct {
+	InputText            string               `json:"inputText"`
+	TextGenerationConfig TextGenerationConfig `json:"textGenerationConfig"`
+}
+
+type TextGenerationConfig struct {
+	MaxTokenCount int      `json:"maxTokenCount,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type TitanTextResponse struct {
+	Results []struct {
+		OutputText string `json:"outputText"`
+	} `json:"results"`
+}
+
+type TitanEmbeddingRequest struct {
+	InputText string `json:"inputText"`
+}
+
+type TitanEmbeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of a test for a model and parameter set.
+type Result struct {
+	Model      string            `json:"model"`
+	Params     string            `json:"params"`
+	Test       string            `json:"test"`
+	Passed     bool              `json:"passed"`
+	Error      string            `json:"error,omitempty"`
+	Output     string            `json:"output,omitempty"`
+	LatencyMS  int64             `json:"latency_ms"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+}
+
+// failures returns the messages of the failed assertions.
+func (r Result) failures() []string {
+	var msgs []string
+	for _, a := range r.Assertions {
+		if !a.Passed {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", a.Type, a.Message))
+		}
+	}
+	return msgs
+}
+
+type Report struct {
+	Suite      string    `json:"suite"`
+	Started    time.Time `json:"started"`
+	DurationMS int64     `json:"duration_ms"`
+	Total      int       `json:"total"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Results    []Result  `json:"results"`
+}
+
+func newReport(suite string, started time.Time, results []Result) Report {
+	r := Report{Suite: suite, Started: started, DurationMS: time.Since(started).Milliseconds(), Results: results}
+	for _, res := range results {
+		r.Total++
+		if res.Passed {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+	}
+	return r
+}
+
+func (r Report) writeJSON(file string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+//JUnit XML model
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Name    string           `xml:"name,attr"`
+	Tests   int              `xml:"tests,attr"`
+	Fail    int              `xml:"failures,attr"`
+	Errors  int              `xml:"errors,attr"`
+	Time    string           `xml:"time,attr"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name   string          `xml:"name,attr"`
+	Tests  int             `xml:"tests,attr"`
+	Fail   int             `xml:"failures,attr"`
+	Errors int             `xml:"errors,attr"`
+	Time   string          `xml:"time,attr"`
+	Cases  []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+// writeJUnit writes the report with a test suite per model and parameter set.
+func (r Report) writeJUnit(file string) error {
+	out := junitTestSuites{Name: r.Suite, Tests: r.Total, Time: seconds(r.DurationMS)}
+
+	index := map[string]int{}
+	latency := map[int]int64{}
+
+	for _, res := range r.Results {
+		class := res.Model + "/" + res.Params
+
+		i, ok := index[class]
+		if !ok {
+			i = len(out.Suites)
+			index[class] = i
+			out.Suites = append(out.Suites, junitTestSuite{Name: fmt.Sprintf("%s [%s]", r.Suite, class)})
+		}
+
+		ts := &out.Suites[i]
+		ts.Tests++
+		latency[i] += res.LatencyMS
+
+		tc := junitTestCase{Name: res.Test, ClassName: class, Time: seconds(res.LatencyMS), SystemOut: res.Output}
+
+		switch {
+		case res.Error != "":
+			ts.Errors++
+			out.Errors++
+			tc.Error = &junitMessage{Message: res.Error, Text: res.Error}
+		case !res.Passed:
+			ts.Fail++
+			out.Fail++
+			msgs := res.failures()
+			tc.Failure = &junitMessage{Message: msgs[0], Text: strings.Join(msgs, "\n")}
+		}
+
+		ts.Cases = append(ts.Cases, tc)
+	}
+
+	for i := range out.Suites {
+		out.Suites[i].Time = seconds(latency[i])
+	}
+
+	b, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, append([]byte(xml.Header), b...), 0644)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// extractJSON returns the JSON value in the output - the content of <json>
+// tags if present, or else the text between the outermost braces or
+// brackets.
+func extractJSON(output string) (interface{}, error) {
+	body := output
+
+	if start := strings.Index(output, "<json>"); start >= 0 {
+		body = output[start+len("<json>"):]
+		if end := strings.Index(body, "</json>"); end >= 0 {
+			body = body[:end]
+		}
+	} else if start := strings.IndexAny(output, "{["); start >= 0 {
+		end := strings.LastIndexAny(output, "}]")
+		if end > start {
+			body = output[start : end+1]
+		}
+	}
+
+	var v interface{}
+	err := json.Unmarshal([]byte(strings.TrimSpace(body)), &v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of test prompts with assertions on the model output, run
+// against every combination of model and parameter set.
+type Suite struct {
+	Name   string     `json:"name" yaml:"name"`
+	Models []string   `json:"models" yaml:"models"`
+	Params []ParamSet `json:"params,omitempty" yaml:"params,omitempty"`
+	// JudgeModel grades llm-judge assertions. Defaults to Claude v2.
+	JudgeModel string `json:"judge_model,omitempty" yaml:"judge_model,omitempty"`
+	// EmbeddingModel is used by similarity assertions. Defaults to Titan
+	// Embeddings G1 - Text.
+	EmbeddingModel string `json:"embedding_model,omitempty" yaml:"embedding_model,omitempty"`
+	Tests          []Test `json:"tests" yaml:"tests"`
+}
+
+// ParamSet is a named set of inference parameters.
+type ParamSet struct {
+	Name        string   `json:"name" yaml:"name"`
+	MaxTokens   int      `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty" yaml:"top_p,omitempty"`
+	TopK        *int     `json:"top_k,omitempty" yaml:"top_k,omitempty"`
+	Stop        []string `json:"stop_sequences,omitempty" yaml:"stop_sequences,omitempty"`
+}
+
+type Test struct {
+	Name   string      `json:"name" yaml:"name"`
+	Prompt string      `json:"prompt" yaml:"prompt"`
+	Assert []Assertion `json:"assert" yaml:"assert"`
+}
+
+// Assertion is a check on the model output. Which fields apply depends on
+// the type - see assertionTypes.
+type Assertion struct {
+	Type string `json:"type" yaml:"type"`
+	// Value is the expected substring (contains), pattern (regex) or
+	// reference answer (similarity).
+	Value      string             `json:"value,omitempty" yaml:"value,omitempty"`
+	IgnoreCase bool               `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty"`
+	Schema     *jsonschema.Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Values are the items expected by extract-match, in order unless
+	// Unordered is set.
+	Values    []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Unordered bool     `json:"unordered,omitempty" yaml:"unordered,omitempty"`
+	// Pattern extracts the items for extract-match. Without it every
+	// non-empty line of the output is an item.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Rubric tells the judge how to grade the output for llm-judge.
+	Rubric string `json:"rubric,omitempty" yaml:"rubric,omitempty"`
+	// Threshold is the minimum cosine similarity (similarity) or score out of
+	// 10 (llm-judge).
+	Threshold float64 `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+}
+
+// loadSuite reads a suite from a .yaml, .yml or .json file.
+func loadSuite(file string) (*Suite, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Suite
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(b, &s)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &s)
+	default:
+		return nil, fmt.Errorf("unsupported suite format %s - use .yaml or .json", file)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid suite %s: %w", file, err)
+	}
+
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if len(s.Params) == 0 {
+		s.Params = []ParamSet{{Name: "default"}}
+	}
+	if s.JudgeModel == "" {
+		s.JudgeModel = claudeV2ModelID
+	}
+	if s.EmbeddingModel == "" {
+		s.EmbeddingModel = titanEmbeddingModelID
+	}
+
+	return &s, s.validate()
+}
+
+func (s *Suite) validate() error {
+	if len(s.Tests) == 0 {
+		return fmt.Errorf("suite %s has no tests", s.Name)
+	}
+
+	for _, t := range s.Tests {
+		if t.Name == "" || t.Prompt == "" {
+			return fmt.Errorf("every test needs a name and a prompt")
+		}
+
+		for i, a := range t.Assert {
+			err := a.validate()
+			if err != nil {
+				return fmt.Errorf("test %s, assertion %d: %w", t.Name, i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const defaultRegion = "us-east-1"
+
+const (
+	claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+	claudeV2ModelID    = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+)
+
+func main() {
+
+	usage := "usage: go run . list|render|invoke [flags] [TEMPLATE]"
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		list(os.Args[2:])
+	case "render":
+		render(os.Args[2:])
+	case "invoke":
+		invoke(os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func list(args []string) {
+
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("dir", "templates", "directory with the prompt templates")
+	fs.Parse(args)
+
+	names, err := listTemplates(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMODEL\tVARIABLES\tDESCRIPTION")
+	for _, name := range names {
+		pt, err := loadTemplate(*dir, name)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		vars := make([]string, 0, len(pt.Vars))
+		for _, v := range pt.Vars {
+			vars = append(vars, v.Name+":"+v.Type)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, pt.Model, strings.Join(vars, ","), pt.Description)
+	}
+	w.Flush()
+}
+
+// render prints the request body for the template without invoking the model.
+func render(args []string) {
+
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	opts := templateFlags(fs)
+	fs.Parse(args)
+
+	_, _, body := opts.requestBody(fs)
+
+	fmt.Print(string(body))
+}
+
+func invoke(args []string) {
+
+	fs := flag.NewFlagSet("invoke", flag.ExitOnError)
+	opts := templateFlags(fs)
+	fs.Parse(args)
+
+	modelID, family, body := opts.requestBody(fs)
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	brc := bedrockruntime.NewFromConfig(cfg)
+
+	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
+		Body:        body,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		log.Fatal("failed to invoke model: ", err)
+	}
+
+	completion, err := completion(family, output.Body)
+	if err != nil {
+		log.Fatal("failed to unmarshal", err)
+	}
+
+	fmt.Println("response from LLM\n", completion)
+}
+
+type templateOptions struct {
+	dir     *string
+	modelID *string
+	vars    keyValueFlag
+	params  keyValueFlag
+}
+
+func templateFlags(fs *flag.FlagSet) *templateOptions {
+	opts := &templateOptions{vars: keyValueFlag{}, params: keyValueFlag{}}
+	opts.dir = fs.String("dir", "templates", "directory with the prompt templates")
+	opts.modelID = fs.String("model", "", "model ID (defaults to the template model, or Claude v2)")
+	fs.Var(opts.vars, "var", "template variable as NAME=VALUE (can be repeated)")
+	fs.Var(opts.params, "param", "inference parameter overriding the template default, as NAME=VALUE (can be repeated)")
+	return opts
+}
+
+// requestBody renders the template named by the first argument and encodes
+// it in the request body format of the model.
+func (opts *templateOptions) requestBody(fs *flag.FlagSet) (string, models.Family, []byte) {
+
+	if fs.NArg() == 0 {
+		log.Fatalf("usage: go run . %s [-dir templates] [-model MODEL_ID] [-var NAME=VALUE] [-param NAME=VALUE] TEMPLATE", fs.Name())
+	}
+
+	pt, err := loadTemplate(*opts.dir, fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prompt, err := pt.Render(opts.vars)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	modelID := *opts.modelID
+	if modelID == "" {
+		modelID = pt.Model
+	}
+	if modelID == "" {
+		modelID = claudeV2ModelID
+	}
+
+	family, err := familyOf(modelID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	params := Params{}
+	for k, v := range pt.Params {
+		params[k] = v
+	}
+	for k, v := range opts.params {
+		params[k] = v
+	}
+
+	payload, err := request(family, prompt, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//keep the tags in the prompt readable in the rendered body
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(payload)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return modelID, family, body.Bytes()
+}
+
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected NAME=VALUE, got %q", value)
+	}
+	f[k] = v
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abhirockzz/amazon-bedrock-go-sdk-examples/internal/models"
+)
+
+// familyOf returns the body format of a model, which must be Claude (v1, v2,
+// Instant) or Cohere Command.
+func familyOf(modelID string) (models.Family, error) {
+	family, err := models.FamilyOf(modelID)
+	if err != nil {
+		return "", err
+	}
+
+	if family != models.Claude && family != models.CohereCommand {
+		return "", fmt.Errorf("unsupported model %s - prompt templates support Claude and Cohere Command models", modelID)
+	}
+	return family, nil
+}
+
+// request wraps the prompt for the model family - Claude expects the
+// Human/Assistant format, Cohere takes the prompt as is - and applies the
+// inference parameters.
+func request(family models.Family, prompt string, params Params) (interface{}, error) {
+
+	maxTokens, err := params.Int("max_tokens", 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	temperature, err := params.OptionalFloat("temperature")
+	if err != nil {
+		return nil, err
+	}
+
+	topP, err := params.OptionalFloat("top_p")
+	if err != nil {
+		return nil, err
+	}
+
+	topK, err := params.OptionalInt("top_k")
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range params {
+		switch key {
+		case "max_tokens", "temperature", "top_p", "top_k", "stop_sequences":
+		default:
+			return nil, fmt.Errorf("unknown inference parameter %s", key)
+		}
+	}
+
+	if family == models.CohereCommand {
+		return CohereRequest{
+			Prompt:        prompt,
+			MaxTokens:     maxTokens,
+			Temperature:   temperature,
+			P:             topP,
+			K:             topK,
+			StopSequences: params.List("stop_sequences"),
+		}, nil
+	}
+
+	return ClaudeRequest{
+		Prompt:            fmt.Sprintf(claudePromptFormat, prompt),
+		MaxTokensToSample: maxTokens,
+		Temperature:       temperature,
+		TopP:              topP,
+		TopK:              topK,
+		StopSequences:     params.List("stop_sequences"),
+	}, nil
+}
+
+func completion(family models.Family, body []byte) (string, error) {
+	if family == models.CohereCommand {
+		var resp CohereResponse
+		err := json.Unmarshal(body, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Generations) == 0 {
+			return "", fmt.Errorf("no generations in the response")
+		}
+		return resp.Generations[0].Text, nil
+	}
+
+	var resp ClaudeResponse
+	err := json.Unmarshal(body, &resp)
+	return resp.Completion, err
+}
+
+//request/response model (Claude)
+
+// Sampling parameters are pointers, so that explicit zeros (e.g. a
+// temperature of 0) are sent rather than omitted.
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              *int     `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+}
+
+//request/response model (Cohere)
+
+type CohereRequest struct {
+	Prompt        string   `json:"prompt"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	P             *float64 `json:"p,omitempty"`
+	K             *int     `json:"k,omitempty"`
+	MaxTokens     int      `json:"max_tokens,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+type CohereResponse struct {
+	Generations []struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	} `json:"generations"`
+}
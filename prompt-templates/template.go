@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const templateExt = ".tmpl"
+
+// PromptTemplate is a prompt loaded from a file. The file starts with front
+// matter between --- lines, with one "key: value" per line:
+//
+//	---
+//	description: Rewrite a paragraph for a younger audience
+//	model: anthropic.claude-v2
+//	max_tokens: 2048
+//	temperature: 0.5
+//	var.paragraph: string
+//	var.grade: int = 5
+//	---
+//
+// Keys other than description, model and var.* are default inference
+// parameters. Variables without a default are required. The rest of the file
+// is a text/template that can include the partials in the partials directory
+// with {{template "name" .}}.
+type PromptTemplate struct {
+	Name        string
+	Description string
+	Model       string
+	Params      Params
+	Vars        []Var
+
+	tmpl *template.Template
+}
+
+// Var is a typed template variable.
+type Var struct {
+	Name    string
+	Type    string
+	Default *string
+}
+
+var varTypes = []string{"string", "int", "float", "bool", "list"}
+
+// convert parses the string value of the variable into its type. Lists are
+// comma separated.
+func (v Var) convert(value string) (interface{}, error) {
+	switch v.Type {
+	case "int":
+		return strconv.Atoi(value)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "list":
+		items := strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
+// Params are inference parameters, keyed by their front matter name.
+type Params map[string]string
+
+func (p Params) Int(key string, def int) (int, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// OptionalFloat returns nil if the parameter is not set, so that a value of
+// 0 can be told apart from the model default.
+func (p Params) OptionalFloat(key string) (*float64, error) {
+	v, ok := p[key]
+	if !ok {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return &f, nil
+}
+
+// OptionalInt returns nil if the parameter is not set.
+func (p Params) OptionalInt(key string) (*int, error) {
+	if _, ok := p[key]; !ok {
+		return nil, nil
+	}
+	n, err := p.Int(key, 0)
+	return &n, err
+}
+
+func (p Params) List(key string) []string {
+	v, ok := p[key]
+	if !ok || v == "" {
+		return nil
+	}
+	items := strings.Split(v, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// funcs are available to templates and partials in addition to the
+// text/template builtins.
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	// dict builds a map from key/value pairs, to pass several values to a
+	// partial.
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("dict expects key/value pairs")
+		}
+		m := map[string]interface{}{}
+		for i := 0; i < len(pairs); i += 2 {
+			k, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings")
+			}
+			m[k] = pairs[i+1]
+		}
+		return m, nil
+	},
+}
+
+// loadTemplate loads dir/name.tmpl together with the partials in
+// dir/partials.
+func loadTemplate(dir, name string) (*PromptTemplate, error) {
+	b, err := os.ReadFile(filepath.Join(dir, name+templateExt))
+	if err != nil {
+		return nil, err
+	}
+
+	pt, body, err := parseFrontMatter(name, string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	pt.tmpl, err = template.New(name).Funcs(funcs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	partials, err := filepath.Glob(filepath.Join(dir, "partials", "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range partials {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = pt.tmpl.New(strings.TrimSuffix(filepath.Base(p), templateExt)).Parse(string(b))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pt, nil
+}
+
+// listTemplates returns the names of the templates in dir.
+func listTemplates(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(f), templateExt))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func parseFrontMatter(name, content string) (*PromptTemplate, string, error) {
+	pt := &PromptTemplate{Name: name, Params: Params{}}
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return pt, content, nil
+	}
+
+	header, body, ok := strings.Cut(strings.TrimPrefix(content, "---\n"), "\n---\n")
+	if !ok {
+		return nil, "", fmt.Errorf("front matter is not closed")
+	}
+
+	for i, line := range strings.Split(header, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("front matter line %d: expected key: value", i+2)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case key == "description":
+			pt.Description = value
+		case key == "model":
+			pt.Model = value
+		case strings.HasPrefix(key, "var."):
+			v, err := parseVar(strings.TrimPrefix(key, "var."), value)
+			if err != nil {
+				return nil, "", fmt.Errorf("front matter line %d: %w", i+2, err)
+			}
+			pt.Vars = append(pt.Vars, v)
+		default:
+			pt.Params[key] = value
+		}
+	}
+
+	return pt, body, nil
+}
+
+// parseVar parses "type" or "type = default".
+func parseVar(name, decl string) (Var, error) {
+	typ, def, hasDefault := strings.Cut(decl, "=")
+
+	v := Var{Name: name, Type: strings.TrimSpace(typ)}
+	if !contains(varTypes, v.Type) {
+		return v, fmt.Errorf("variable %s has unknown type %q - use one of %s", name, v.Type, strings.Join(varTypes, ", "))
+	}
+
+	if hasDefault {
+		d := strings.TrimSpace(def)
+		_, err := v.convert(d)
+		if err != nil {
+			return v, fmt.Errorf("invalid default for variable %s: %w", name, err)
+		}
+		v.Default = &d
+	}
+
+	return v, nil
+}
+
+// Render executes the template with the given variable values, which are
+// converted to the declared types. Defaults are used for values not given.
+func (pt *PromptTemplate) Render(values map[string]string) (string, error) {
+	data := map[string]interface{}{}
+
+	for _, v := range pt.Vars {
+		value, ok := values[v.Name]
+		if !ok {
+			if v.Default == nil {
+				return "", fmt.Errorf("missing value for variable %s (%s)", v.Name, v.Type)
+			}
+			value = *v.Default
+		}
+
+		typed, err := v.convert(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for variable %s (%s): %w", v.Name, v.Type, err)
+		}
+		data[v.Name] = typed
+	}
+
+	for name := range values {
+		if _, ok := data[name]; !ok {
+			return "", fmt.Errorf("template %s has no variable %s", pt.Name, name)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := pt.tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
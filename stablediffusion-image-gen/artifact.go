@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// artifact finish reasons
+const (
+	FinishReasonSuccess         = "SUCCESS"
+	FinishReasonContentFiltered = "CONTENT_FILTERED"
+	FinishReasonError           = "ERROR"
+)
+
+// ErrContentFiltered and ErrArtifact can be matched with errors.Is against an
+// *ArtifactError to tell filtered results apart from generation failures.
+var (
+	ErrContentFiltered = errors.New("artifact was content filtered")
+	ErrArtifact        = errors.New("artifact generation failed")
+)
+
+// ArtifactError is returned for an artifact whose finish reason is not SUCCESS.
+type ArtifactError struct {
+	Index        int
+	FinishReason string
+}
+
+func (e *ArtifactError) Error() string {
+	return fmt.Sprintf("artifact %d finished with reason %s", e.Index, e.FinishReason)
+}
+
+func (e *ArtifactError) Is(target error) bool {
+	switch target {
+	case ErrContentFiltered:
+		return e.FinishReason == FinishReasonContentFiltered
+	case ErrArtifact:
+		return e.FinishReason != FinishReasonContentFiltered
+	}
+	return false
+}
+
+// ArtifactErrors collects the errors of every artifact that was not written.
+type ArtifactErrors []*ArtifactError
+
+func (e ArtifactErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the artifact errors matches target.
+func (e ArtifactErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an *ArtifactError unless the artifact finished successfully.
+func (a *Artifact) check(index int) error {
+	switch a.FinishReason {
+	case FinishReasonSuccess, "":
+		return nil
+	default:
+		return &ArtifactError{Index: index, FinishReason: a.FinishReason}
+	}
+}
+
+// writeArtifacts writes every successful artifact to its own file named
+// <prefix>-<index>.<ext>, where the extension matches the decoded image
+// format, with the generation metadata embedded and in a JSON sidecar.
+// Artifacts that were filtered or failed are skipped and reported in the
+// returned ArtifactErrors.
+func writeArtifacts(artifacts []Artifact, prefix string, meta Metadata) ([]string, error) {
+
+	var files []string
+	var failed ArtifactErrors
+
+	for i := range artifacts {
+		err := artifacts[i].check(i)
+		if err != nil {
+			failed = append(failed, err.(*ArtifactError))
+			continue
+		}
+
+		decoded, err := artifacts[i].DecodeImage()
+		if err != nil {
+			return files, fmt.Errorf("failed to decode artifact %d: %w", i, err)
+		}
+
+		ext, err := imageExtension(decoded)
+		if err != nil {
+			return files, fmt.Errorf("artifact %d: %w", i, err)
+		}
+
+		outputFile := fmt.Sprintf("%s-%d.%s", prefix, i, ext)
+
+		m := meta.forArtifact(artifacts[i])
+
+		decoded, err = embedMetadata(decoded, ext, m)
+		if err != nil {
+			return files, fmt.Errorf("failed to embed metadata in artifact %d: %w", i, err)
+		}
+
+		err = os.WriteFile(outputFile, decoded, 0644)
+		if err != nil {
+			return files, err
+		}
+
+		err = writeSidecar(outputFile, m)
+		if err != nil {
+			return files, err
+		}
+
+		files = append(files, outputFile)
+	}
+
+	if len(failed) > 0 {
+		return files, failed
+	}
+
+	return files, nil
+}
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	jpegSignature = []byte{0xff, 0xd8, 0xff}
+)
+
+// imageExtension detects the image format from the decoded bytes.
+func imageExtension(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		return "png", nil
+	case bytes.HasPrefix(data, jpegSignature):
+		return "jpg", nil
+	}
+
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/webp":
+		return "webp", nil
+	case "image/gif":
+		return "gif", nil
+	default:
+		return "", fmt.Errorf("unsupported image format %s", contentType)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+	claudeV2ModelID    = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+)
+
+const enhancePromptTemplate = `<idea>
+%s
+</idea>
+
+You are an expert at writing prompts for the Stable Diffusion XL image model. Please expand the above idea into a detailed prompt that describes the subject, setting, composition, lighting, colors, style and camera or medium. Keep it under 75 words and write it as a comma separated list of descriptive phrases.
+
+Also write a negative prompt listing things that should not appear in the image, such as common artifacts and qualities that would conflict with the idea.
+
+Please output the prompt in <prompt></prompt> tags and the negative prompt in <negative_prompt></negative_prompt> tags.`
+
+// enhancePrompt asks Claude to turn a short prompt into a detailed SDXL
+// prompt and a negative prompt.
+func enhancePrompt(prompt string) (string, string, error) {
+
+	payload := ClaudeRequest{
+		Prompt:            fmt.Sprintf(claudePromptFormat, fmt.Sprintf(enhancePromptTemplate, prompt)),
+		MaxTokensToSample: 500,
+		Temperature:       0.7,
+	}
+
+	var resp ClaudeResponse
+
+	err := invoke(claudeV2ModelID, payload, &resp)
+	if err != nil {
+		return "", "", err
+	}
+
+	enhanced, ok := extractTag(resp.Completion, "prompt")
+	if !ok || enhanced == "" {
+		return "", "", fmt.Errorf("no <prompt> tag in response: %s", resp.Completion)
+	}
+
+	negative, _ := extractTag(resp.Completion, "negative_prompt")
+
+	return enhanced, negative, nil
+}
+
+// extractTag returns the trimmed content of the first <tag></tag> pair.
+func extractTag(s, tag string) (string, bool) {
+	open, close := "<"+tag+">", "</"+tag+">"
+
+	start := strings.Index(s, open)
+	if start == -1 {
+		return "", false
+	}
+	s = s[start+len(open):]
+
+	if end := strings.Index(s, close); end != -1 {
+		s = s[:end]
+	}
+
+	return strings.TrimSpace(s), true
+}
+
+//request/response model (Claude)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       float64  `json:"temperature,omitempty"`
+	TopP              float64  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+}
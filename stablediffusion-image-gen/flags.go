@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// requestFlags holds the command line flags that make up an SDXL request, so
+// that they can be shared between commands.
+type requestFlags struct {
+	modelID *string
+
+	prompts, negativePrompts TextPrompts
+
+	height, width      *int
+	cfgScale           *float64
+	steps              *int
+	seed               *int64
+	sampler            *string
+	stylePreset        *string
+	clipGuidancePreset *string
+	samples            *int
+
+	initImage         *string
+	initImageMode     *string
+	imageStrength     *float64
+	stepScheduleStart *float64
+	stepScheduleEnd   *float64
+	maskSource        *string
+	maskImage         *string
+	fit               *string
+
+	//Titan Image Generator only
+	taskType        *string
+	quality         *string
+	maskPrompt      *string
+	outPaintingMode *string
+}
+
+func newRequestFlags(fs *flag.FlagSet) *requestFlags {
+	f := &requestFlags{}
+
+	f.modelID = fs.String("model", stableDiffusionXLModelID, "image model ID - "+stableDiffusionXLModelID+" or "+titanImageGeneratorModelID)
+	f.height = fs.Int("height", 1024, "image height in pixels")
+	f.width = fs.Int("width", 1024, "image width in pixels")
+	f.cfgScale = fs.Float64("cfg-scale", 10, "how strictly the diffusion process adheres to the prompt (0 to 35)")
+	f.steps = fs.Int("steps", 50, "number of diffusion steps (10 to 150)")
+	f.seed = fs.Int64("seed", -1, "seed for the noise (0 to 4294967295) - a random seed is used if negative")
+	f.sampler = fs.String("sampler", "", "sampler to use for the diffusion process (e.g. K_DPMPP_2M)")
+	f.stylePreset = fs.String("style-preset", "", "style preset to guide the image (e.g. photographic, anime, digital-art)")
+	f.clipGuidancePreset = fs.String("clip-guidance-preset", "", "CLIP guidance preset (e.g. FAST_BLUE, SIMPLE, NONE)")
+	f.samples = fs.Int("samples", 1, "number of images to generate (always 1 for SDXL, up to 5 for Titan Image Generator)")
+	fs.Var(&f.prompts, "prompt", "additional prompt, optionally weighted as text:weight (repeatable)")
+	fs.Var(&f.negativePrompts, "negative", "negative prompt, optionally weighted as text:weight (repeatable, default weight -1)")
+	f.initImage = fs.String("init-image", "", "PNG or JPEG file to use as the initial image (image-to-image)")
+	f.initImageMode = fs.String("init-image-mode", "IMAGE_STRENGTH", "how the initial image influences the result - IMAGE_STRENGTH or STEP_SCHEDULE")
+	f.imageStrength = fs.Float64("image-strength", 0.35, "influence of the initial image (0 to 1) when using IMAGE_STRENGTH")
+	f.stepScheduleStart = fs.Float64("step-schedule-start", 0.65, "start of the step schedule (0 to 1) when using STEP_SCHEDULE")
+	f.stepScheduleEnd = fs.Float64("step-schedule-end", 1, "end of the step schedule (0 to 1) when using STEP_SCHEDULE")
+	f.maskSource = fs.String("mask-source", "", "mask for inpainting - MASK_IMAGE_WHITE, MASK_IMAGE_BLACK or INIT_IMAGE_ALPHA")
+	f.maskImage = fs.String("mask-image", "", "PNG or JPEG file to use as the mask when using MASK_IMAGE_WHITE or MASK_IMAGE_BLACK")
+	f.fit = fs.String("fit", fitResize, "how to handle input images that don't match the requested dimensions - none, resize or pad")
+	f.taskType = fs.String("task-type", TaskTextImage, "Titan Image Generator task type - TEXT_IMAGE, INPAINTING, OUTPAINTING or IMAGE_VARIATION")
+	f.quality = fs.String("quality", "standard", "Titan Image Generator quality - standard or premium")
+	f.maskPrompt = fs.String("mask-prompt", "", "Titan Image Generator prompt describing the area to mask (instead of -mask-image)")
+	f.outPaintingMode = fs.String("outpainting-mode", "DEFAULT", "Titan Image Generator outpainting mode - DEFAULT or PRECISE")
+
+	return f
+}
+
+// request builds and validates the request from the parsed flags. prompt, if
+// not empty, is used as the main prompt ahead of any -prompt flags.
+func (f *requestFlags) request(prompt string) (Request, error) {
+
+	prompts := append(TextPrompts{}, f.prompts...)
+	if prompt != "" {
+		prompts = append(TextPrompts{{Text: prompt, Weight: 1}}, prompts...)
+	}
+
+	for _, np := range f.negativePrompts {
+		if np.Weight > 0 {
+			np.Weight = -np.Weight
+		}
+		prompts = append(prompts, np)
+	}
+
+	seed := *f.seed
+	if seed < 0 {
+		seed = randomSeed()
+	}
+
+	payload := Request{
+		TextPrompts:        prompts,
+		Height:             *f.height,
+		Width:              *f.width,
+		CfgScale:           *f.cfgScale,
+		ClipGuidancePreset: *f.clipGuidancePreset,
+		Sampler:            *f.sampler,
+		Samples:            *f.samples,
+		Seed:               seed,
+		Steps:              *f.steps,
+		StylePreset:        *f.stylePreset,
+	}
+
+	if *f.maskImage != "" && (*f.initImage == "" || *f.maskSource == "") {
+		return payload, fmt.Errorf("mask-image requires init-image and mask-source")
+	}
+
+	if *f.initImage != "" {
+		payload.InitImageMode = *f.initImageMode
+		switch payload.InitImageMode {
+		case "IMAGE_STRENGTH":
+			payload.ImageStrength = *f.imageStrength
+		case "STEP_SCHEDULE":
+			payload.StepScheduleStart = *f.stepScheduleStart
+			payload.StepScheduleEnd = *f.stepScheduleEnd
+		}
+		payload.MaskSource = *f.maskSource
+	}
+
+	err := payload.Validate()
+	if err != nil {
+		return payload, err
+	}
+
+	if *f.initImage != "" {
+		payload.InitImage, err = loadInputImage(*f.initImage, payload.Width, payload.Height, *f.fit)
+		if err != nil {
+			return payload, fmt.Errorf("failed to load initial image: %w", err)
+		}
+
+		if *f.maskImage != "" {
+			payload.MaskImage, err = loadInputImage(*f.maskImage, payload.Width, payload.Height, *f.fit)
+			if err != nil {
+				return payload, fmt.Errorf("failed to load mask image: %w", err)
+			}
+		}
+
+		//the output dimensions are taken from the initial image
+		payload.Width, payload.Height = 0, 0
+	}
+
+	if payload.MaskSource != "" && payload.MaskSource != "INIT_IMAGE_ALPHA" && payload.MaskImage == "" {
+		return payload, fmt.Errorf("mask-image is required with mask source %s", payload.MaskSource)
+	}
+
+	return payload, nil
+}
+
+func randomSeed() int64 {
+	return int64(rand.New(rand.NewSource(time.Now().UnixNano())).Uint32())
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	//register decoders for the supported input formats
+	_ "image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// fit modes for input images that do not match the requested dimensions
+const (
+	fitNone   = "none"
+	fitResize = "resize"
+	fitPad    = "pad"
+)
+
+// loadInputImage reads a PNG or JPEG file and returns it as a base64-encoded
+// PNG of exactly width x height pixels. Images of a different size are
+// rejected, stretched, or scaled to fit and padded depending on fit.
+func loadInputImage(file string, width, height int, fit string) (string, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, format, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+
+	size := img.Bounds().Size()
+	if size.X != width || size.Y != height {
+		switch fit {
+		case fitNone:
+			return "", fmt.Errorf("%s is %dx%d (%s) but the requested dimensions are %dx%d", file, size.X, size.Y, format, width, height)
+		case fitResize:
+			img = resize(img, width, height)
+		case fitPad:
+			img = pad(img, width, height)
+		default:
+			return "", fmt.Errorf("invalid fit mode %q - must be none, resize or pad", fit)
+		}
+		fmt.Printf("%s is %dx%d - %s to %dx%d\n", file, size.X, size.Y, fitVerb(fit), width, height)
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func fitVerb(fit string) string {
+	if fit == fitPad {
+		return "padded"
+	}
+	return "resized"
+}
+
+// resize stretches the image to exactly width x height.
+func resize(src image.Image, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// pad scales the image to fit within width x height while keeping its aspect
+// ratio, and centers it on a transparent canvas. With INIT_IMAGE_ALPHA as the
+// mask source, the padded area is the one that gets painted.
+func pad(src image.Image, width, height int) image.Image {
+	size := src.Bounds().Size()
+
+	scale := float64(width) / float64(size.X)
+	if s := float64(height) / float64(size.Y); s < scale {
+		scale = s
+	}
+
+	w, h := int(float64(size.X)*scale), int(float64(size.Y)*scale)
+	offset := image.Pt((width-w)/2, (height-h)/2)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Transparent), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(w, h))}, src, src.Bounds(), draw.Over, nil)
+	return dst
+}
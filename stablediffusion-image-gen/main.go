@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -20,7 +22,9 @@ const (
 	stableDiffusionXLModelID = "stability.stable-diffusion-xl-v0" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
 )
 
-func main() {
+var brc *bedrockruntime.Client
+
+func init() {
 
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -32,70 +36,168 @@ func main() {
 		log.Fatal(err)
 	}
 
-	brc := bedrockruntime.NewFromConfig(cfg)
+	brc = bedrockruntime.NewFromConfig(cfg)
+}
 
-	prompt := os.Args[1]
-	fmt.Println("generating image based on prompt -", prompt)
+func main() {
 
-	payload := Request{
-		TextPrompts: []TextPrompt{{Text: prompt}},
-		CfgScale:    10,
-		Seed:        0,
-		Steps:       50,
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reproduce":
+			reproduce(os.Args[2:])
+			return
+		case "sweep":
+			sweep(os.Args[2:])
+			return
+		}
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		log.Fatal(err)
+	rf := newRequestFlags(flag.CommandLine)
+	enhance := flag.Bool("enhance", false, "use Claude to expand the prompt into a detailed prompt and a negative prompt")
+	flag.Parse()
+
+	if flag.NArg() == 0 && len(rf.prompts) == 0 {
+		log.Fatal("usage: go run . [flags] <prompt>")
 	}
 
-	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
-		Body:        payloadBytes,
-		ModelId:     aws.String(stableDiffusionXLModelID),
-		ContentType: aws.String("application/json"),
-	})
+	prompt := flag.Arg(0)
+
+	if *enhance {
+		if prompt == "" {
+			log.Fatal("-enhance requires a prompt argument")
+		}
+
+		fmt.Println("enhancing prompt -", prompt)
+
+		enhanced, negative, err := enhancePrompt(prompt)
+		if err != nil {
+			log.Fatal("failed to enhance prompt: ", err)
+		}
+
+		fmt.Println("\nenhanced prompt -", enhanced)
+		if negative != "" {
+			fmt.Println("negative prompt -", negative)
+			rf.negativePrompts = append(rf.negativePrompts, TextPrompt{Text: negative, Weight: 1})
+		}
+		fmt.Println()
+
+		prompt = enhanced
+	}
+
+	prefix := fmt.Sprintf("output-%d", time.Now().Unix())
+
+	var files []string
+
+	if isTitanImageModel(*rf.modelID) {
+		payload, err := rf.titanRequest(prompt)
+		if err != nil {
+			log.Fatal("invalid request: ", err)
+		}
+
+		fmt.Println("generating image with", *rf.modelID, "task type", payload.TaskType)
+		fmt.Println("seed -", payload.ImageGenerationConfig.Seed)
+
+		files, err = generateTitan(*rf.modelID, payload, prefix)
+		exit(files, err)
+		return
+	}
 
+	payload, err := rf.request(prompt)
 	if err != nil {
-		log.Fatal("failed to invoke model: ", err)
+		log.Fatal("invalid request: ", err)
 	}
 
-	var resp Response
+	fmt.Println("generating image based on prompt -", payload.TextPrompts)
+	fmt.Println("seed -", payload.Seed)
+
+	files, err = generate(*rf.modelID, payload, prefix)
+	exit(files, err)
+}
+
+// exit reports the written files and exits if generation failed.
+func exit(files []string, err error) {
+
+	for _, file := range files {
+		log.Println("image written to file", file)
+	}
 
-	err = json.Unmarshal(output.Body, &resp)
+	if errors.Is(err, ErrContentFiltered) {
+		log.Fatal("one or more images were content filtered: ", err)
+	}
 
 	if err != nil {
-		log.Fatal("failed to unmarshal", err)
+		log.Fatal("failed to generate images: ", err)
 	}
+}
+
+// generate invokes the model and writes every resulting image, along with the
+// metadata needed to reproduce it, to files starting with prefix.
+func generate(modelID string, payload Request, prefix string) ([]string, error) {
 
-	decoded, err := resp.Artifacts[0].DecodeImage()
+	var resp Response
 
+	err := invoke(modelID, payload, &resp)
 	if err != nil {
-		log.Fatal("failed to decode base64 response", err)
+		return nil, err
+	}
 
+	return writeArtifacts(resp.Artifacts, prefix, newMetadata(modelID, payload))
+}
+
+// invoke sends the payload to the model and decodes the response into resp.
+func invoke(modelID string, payload, resp interface{}) error {
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
 	}
 
-	outputFile := fmt.Sprintf("output-%d.jpg", time.Now().Unix())
+	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(modelID),
+		ContentType: aws.String("application/json"),
+	})
 
-	err = os.WriteFile(outputFile, decoded, 0644)
 	if err != nil {
-		fmt.Println("error writing to file:", err)
+		return fmt.Errorf("failed to invoke model: %w", err)
 	}
 
-	log.Println("image written to file", outputFile)
+	err = json.Unmarshal(output.Body, resp)
+
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal: %w", err)
+	}
 
+	return nil
 }
 
 //request/response model
 
 type Request struct {
-	TextPrompts []TextPrompt `json:"text_prompts"`
-	CfgScale    float64      `json:"cfg_scale"`
-	Steps       int          `json:"steps"`
-	Seed        int          `json:"seed"`
+	TextPrompts        TextPrompts `json:"text_prompts"`
+	Height             int         `json:"height,omitempty"`
+	Width              int         `json:"width,omitempty"`
+	CfgScale           float64     `json:"cfg_scale"`
+	ClipGuidancePreset string      `json:"clip_guidance_preset,omitempty"`
+	Sampler            string      `json:"sampler,omitempty"`
+	Samples            int         `json:"samples,omitempty"`
+	Seed               int64       `json:"seed"`
+	Steps              int         `json:"steps"`
+	StylePreset        string      `json:"style_preset,omitempty"`
+
+	//image-to-image and inpainting
+	InitImage         string  `json:"init_image,omitempty"`
+	InitImageMode     string  `json:"init_image_mode,omitempty"`
+	ImageStrength     float64 `json:"image_strength,omitempty"`
+	StepScheduleStart float64 `json:"step_schedule_start,omitempty"`
+	StepScheduleEnd   float64 `json:"step_schedule_end,omitempty"`
+	MaskSource        string  `json:"mask_source,omitempty"`
+	MaskImage         string  `json:"mask_image,omitempty"`
 }
 
 type TextPrompt struct {
-	Text string `json:"text"`
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight,omitempty"`
 }
 
 type Response struct {
@@ -106,6 +208,7 @@ type Response struct {
 type Artifact struct {
 	Base64       string `json:"base64"`
 	FinishReason string `json:"finishReason"`
+	Seed         int64  `json:"seed"`
 }
 
 func (a *Artifact) DecodeImage() ([]byte, error) {
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metadataKey is the PNG iTXt keyword holding the complete metadata as JSON.
+const metadataKey = "bedrock-metadata"
+
+var errNoMetadata = errors.New("no generation metadata found")
+
+// Metadata records how an image was generated. Request (SDXL) or
+// TitanRequest is the exact request body, so that the image can be reproduced
+// with the reproduce command.
+type Metadata struct {
+	ModelID        string             `json:"model_id"`
+	Prompt         string             `json:"prompt"`
+	NegativePrompt string             `json:"negative_prompt,omitempty"`
+	Seed           int64              `json:"seed"`
+	Steps          int                `json:"steps,omitempty"`
+	CfgScale       float64            `json:"cfg_scale"`
+	Sampler        string             `json:"sampler,omitempty"`
+	StylePreset    string             `json:"style_preset,omitempty"`
+	Request        *Request           `json:"request,omitempty"`
+	TitanRequest   *TitanImageRequest `json:"titan_request,omitempty"`
+}
+
+func newMetadata(modelID string, r Request) Metadata {
+	var prompts, negativePrompts []string
+	for _, p := range r.TextPrompts {
+		if p.Weight < 0 {
+			negativePrompts = append(negativePrompts, p.Text)
+		} else {
+			prompts = append(prompts, p.Text)
+		}
+	}
+
+	return Metadata{
+		ModelID:        modelID,
+		Prompt:         strings.Join(prompts, " | "),
+		NegativePrompt: strings.Join(negativePrompts, " | "),
+		Seed:           r.Seed,
+		Steps:          r.Steps,
+		CfgScale:       r.CfgScale,
+		Sampler:        r.Sampler,
+		StylePreset:    r.StylePreset,
+		Request:        &r,
+	}
+}
+
+func newTitanMetadata(modelID string, r TitanImageRequest) Metadata {
+	m := Metadata{ModelID: modelID, TitanRequest: &r}
+
+	if c := r.ImageGenerationConfig; c != nil {
+		m.Seed, m.CfgScale = c.Seed, c.CfgScale
+	}
+
+	switch {
+	case r.TextToImageParams != nil:
+		m.Prompt, m.NegativePrompt = r.TextToImageParams.Text, r.TextToImageParams.NegativeText
+	case r.InPaintingParams != nil:
+		m.Prompt, m.NegativePrompt = r.InPaintingParams.Text, r.InPaintingParams.NegativeText
+	case r.OutPaintingParams != nil:
+		m.Prompt, m.NegativePrompt = r.OutPaintingParams.Text, r.OutPaintingParams.NegativeText
+	case r.ImageVariationParams != nil:
+		m.Prompt, m.NegativePrompt = r.ImageVariationParams.Text, r.ImageVariationParams.NegativeText
+	}
+
+	return m
+}
+
+// forArtifact returns the metadata for a single artifact. When several samples
+// are requested each artifact has its own seed, so the request is narrowed
+// down to that seed and a single sample.
+func (m Metadata) forArtifact(a Artifact) Metadata {
+	if m.Request != nil && a.Seed != 0 && a.Seed != m.Seed {
+		r := *m.Request
+		r.Seed = a.Seed
+		r.Samples = 1
+		m.Seed, m.Request = a.Seed, &r
+	}
+	return m
+}
+
+// withoutImages strips the base64 input images, which are too large to embed
+// in the generated image. They are kept in the JSON sidecar.
+func (m Metadata) withoutImages() Metadata {
+	if m.Request != nil {
+		r := *m.Request
+		r.InitImage, r.MaskImage = "", ""
+		m.Request = &r
+	}
+
+	if m.TitanRequest != nil {
+		r := *m.TitanRequest
+		if p := r.InPaintingParams; p != nil {
+			r.InPaintingParams = &InPaintingParams{Text: p.Text, NegativeText: p.NegativeText, MaskPrompt: p.MaskPrompt}
+		}
+		if p := r.OutPaintingParams; p != nil {
+			r.OutPaintingParams = &OutPaintingParams{Text: p.Text, NegativeText: p.NegativeText, MaskPrompt: p.MaskPrompt, OutPaintingMode: p.OutPaintingMode}
+		}
+		if p := r.ImageVariationParams; p != nil {
+			r.ImageVariationParams = &ImageVariationParams{Text: p.Text, NegativeText: p.NegativeText}
+		}
+		m.TitanRequest = &r
+	}
+
+	return m
+}
+
+// missingImages reports whether the request needs input images that are missing,
+// which is the case for metadata read back from the generated image itself.
+func (m Metadata) missingImages() bool {
+	if r := m.Request; r != nil {
+		return r.InitImageMode != "" && r.InitImage == ""
+	}
+
+	if r := m.TitanRequest; r != nil {
+		return (r.InPaintingParams != nil && r.InPaintingParams.Image == "") ||
+			(r.OutPaintingParams != nil && r.OutPaintingParams.Image == "") ||
+			(r.ImageVariationParams != nil && len(r.ImageVariationParams.Images) == 0)
+	}
+
+	return false
+}
+
+func sidecarFile(imageFile string) string {
+	return strings.TrimSuffix(imageFile, filepath.Ext(imageFile)) + ".json"
+}
+
+func writeSidecar(imageFile string, m Metadata) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sidecarFile(imageFile), b, 0644)
+}
+
+// embedMetadata stores the metadata in PNG iTXt chunks or, for JPEG, in the
+// EXIF ImageDescription tag.
+func embedMetadata(data []byte, ext string, m Metadata) ([]byte, error) {
+	b, err := json.Marshal(m.withoutImages())
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext {
+	case "png":
+		return embedPNGText(data, []textEntry{
+			{"prompt", m.Prompt},
+			{"negative_prompt", m.NegativePrompt},
+			{"model_id", m.ModelID},
+			{"seed", strconv.FormatInt(m.Seed, 10)},
+			{"steps", strconv.Itoa(m.Steps)},
+			{"cfg_scale", strconv.FormatFloat(m.CfgScale, 'f', -1, 64)},
+			{"sampler", m.Sampler},
+			{"style_preset", m.StylePreset},
+			{metadataKey, string(b)},
+		})
+	case "jpg":
+		return embedJPEGExif(data, string(b))
+	default:
+		//metadata is only available in the sidecar for other formats
+		return data, nil
+	}
+}
+
+// extractMetadata reads the metadata embedded by embedMetadata.
+func extractMetadata(data []byte) (Metadata, error) {
+	var raw string
+	var err error
+
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		var text map[string]string
+		text, err = readPNGText(data)
+		raw = text[metadataKey]
+	case bytes.HasPrefix(data, jpegSignature):
+		raw, err = readJPEGExifDescription(data)
+	default:
+		return Metadata{}, errNoMetadata
+	}
+
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if raw == "" {
+		return Metadata{}, errNoMetadata
+	}
+
+	var m Metadata
+	err = json.Unmarshal([]byte(raw), &m)
+	return m, err
+}
+
+type textEntry struct {
+	keyword, text string
+}
+
+// embedPNGText inserts an iTXt chunk per (non empty) entry right after IHDR.
+// iTXt is used rather than tEXt because tEXt is limited to Latin-1, and the
+// prompts are UTF-8.
+func embedPNGText(data []byte, entries []textEntry) ([]byte, error) {
+	if len(data) < 33 || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("invalid PNG")
+	}
+
+	ihdrEnd := 8 + 12 + int(binary.BigEndian.Uint32(data[8:12]))
+
+	var out bytes.Buffer
+	out.Write(data[:ihdrEnd])
+	for _, e := range entries {
+		if e.text == "" {
+			continue
+		}
+		//uncompressed, with empty language tag and translated keyword
+		out.Write(pngChunk("iTXt", []byte(e.keyword+"\x00\x00\x00\x00\x00"+e.text)))
+	}
+	out.Write(data[ihdrEnd:])
+
+	return out.Bytes(), nil
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	chunk := make([]byte, 8+len(data)+4)
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(data)))
+	copy(chunk[4:8], typ)
+	copy(chunk[8:], data)
+	binary.BigEndian.PutUint32(chunk[8+len(data):], crc32.ChecksumIEEE(chunk[4:8+len(data)]))
+	return chunk
+}
+
+func readPNGText(data []byte) (map[string]string, error) {
+	text := map[string]string{}
+
+	for pos := len(pngSignature); pos+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if end+4 > len(data) {
+			return nil, fmt.Errorf("truncated PNG chunk %s", typ)
+		}
+
+		switch typ {
+		case "tEXt":
+			if k, v, ok := bytes.Cut(data[pos+8:end], []byte{0}); ok {
+				text[string(k)] = string(v)
+			}
+		case "iTXt":
+			if k, v, ok := parseITXt(data[pos+8 : end]); ok {
+				text[k] = v
+			}
+		}
+
+		if typ == "IEND" {
+			break
+		}
+		pos = end + 4
+	}
+
+	return text, nil
+}
+
+// parseITXt returns the keyword and text of an uncompressed iTXt chunk.
+func parseITXt(data []byte) (string, string, bool) {
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(rest) < 2 || rest[0] != 0 {
+		return "", "", false
+	}
+
+	//skip the compression method, language tag and translated keyword
+	rest = rest[2:]
+	for i := 0; i < 2; i++ {
+		_, rest, ok = bytes.Cut(rest, []byte{0})
+		if !ok {
+			return "", "", false
+		}
+	}
+
+	return string(keyword), string(rest), true
+}
+
+const (
+	jpegAPP1             = 0xe1
+	exifImageDescription = 0x010e
+	exifTypeASCII        = 2
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// embedJPEGExif inserts an APP1 EXIF segment with a single ImageDescription
+// tag right after the SOI marker.
+func embedJPEGExif(data []byte, description string) ([]byte, error) {
+	value := append([]byte(description), 0)
+
+	//big endian TIFF header, followed by IFD0 with one entry pointing to the value
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(exifImageDescription))
+	binary.Write(&tiff, binary.BigEndian, uint16(exifTypeASCII))
+	binary.Write(&tiff, binary.BigEndian, uint32(len(value)))
+	binary.Write(&tiff, binary.BigEndian, uint32(8+2+12+4))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.Write(value)
+
+	length := 2 + len(exifHeader) + tiff.Len()
+	if length > 0xffff {
+		return nil, fmt.Errorf("metadata too large for an EXIF segment")
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, jpegAPP1})
+	binary.Write(&out, binary.BigEndian, uint16(length))
+	out.Write(exifHeader)
+	out.Write(tiff.Bytes())
+	out.Write(data[2:])
+
+	return out.Bytes(), nil
+}
+
+func readJPEGExifDescription(data []byte) (string, error) {
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xff; {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if pos+2+length > len(data) {
+			return "", fmt.Errorf("truncated JPEG segment")
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == jpegAPP1 && bytes.HasPrefix(segment, exifHeader) {
+			return readTIFFDescription(segment[len(exifHeader):])
+		}
+
+		//metadata segments come before the image data
+		if marker == 0xda {
+			break
+		}
+		pos += 2 + length
+	}
+
+	return "", nil
+}
+
+func readTIFFDescription(tiff []byte) (string, error) {
+	if len(tiff) < 8 {
+		return "", fmt.Errorf("invalid EXIF data")
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if string(tiff[:2]) == "II" {
+		order = binary.LittleEndian
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return "", fmt.Errorf("invalid EXIF IFD offset")
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) != exifImageDescription {
+			continue
+		}
+
+		n := int(order.Uint32(tiff[entry+4 : entry+8]))
+		offset := entry + 8
+		if n > 4 {
+			offset = int(order.Uint32(tiff[entry+8 : entry+12]))
+		}
+		if offset+n > len(tiff) {
+			return "", fmt.Errorf("invalid EXIF value offset")
+		}
+		return strings.TrimRight(string(tiff[offset:offset+n]), "\x00"), nil
+	}
+
+	return "", nil
+}
+
+// readMetadata loads the metadata from a JSON sidecar, from the sidecar next
+// to an image, or from the image itself.
+func readMetadata(file string) (Metadata, error) {
+	var m Metadata
+
+	if filepath.Ext(file) != ".json" {
+		if _, err := os.Stat(sidecarFile(file)); err != nil {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return m, err
+			}
+			return extractMetadata(data)
+		}
+		file = sidecarFile(file)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// reproduce re-issues the request recorded in an image's metadata.
+func reproduce(args []string) {
+
+	fs := flag.NewFlagSet("reproduce", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: go run . reproduce <image or sidecar file>")
+	}
+
+	m, err := readMetadata(fs.Arg(0))
+	if err != nil {
+		log.Fatal("failed to read metadata: ", err)
+	}
+
+	if m.missingImages() {
+		log.Fatal("the input images are not embedded in the image - reproduce from the JSON sidecar instead")
+	}
+
+	fmt.Println("reproducing image based on prompt -", m.Prompt)
+	fmt.Println("model -", m.ModelID, "| seed -", m.Seed, "| steps -", m.Steps, "| cfg scale -", m.CfgScale)
+
+	prefix := fmt.Sprintf("output-%d", time.Now().Unix())
+
+	var files []string
+	switch {
+	case m.Request != nil:
+		files, err = generate(m.ModelID, *m.Request, prefix)
+	case m.TitanRequest != nil:
+		files, err = generateTitan(m.ModelID, *m.TitanRequest, prefix)
+	default:
+		err = errNoMetadata
+	}
+
+	exit(files, err)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func encodeTestImage(t *testing.T, ext string) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	var err error
+	if ext == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	m := newMetadata("stability.stable-diffusion-xl-v1", Request{
+		TextPrompts: TextPrompts{{Text: "a café at dusk, 東京", Weight: 1}, {Text: "blurry", Weight: -1}},
+		CfgScale:    7.5,
+		Seed:        42,
+		Steps:       30,
+		Sampler:     "K_DPMPP_2M",
+		StylePreset: "photographic",
+		InitImage:   "aW1hZ2U=",
+	})
+
+	for _, ext := range []string{"png", "jpg"} {
+		t.Run(ext, func(t *testing.T) {
+			data, err := embedMetadata(encodeTestImage(t, ext), ext, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+				t.Fatalf("image no longer decodes: %v", err)
+			}
+
+			got, err := extractMetadata(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := m.withoutImages()
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+			if got.Request.InitImage != "" {
+				t.Fatal("input image should not be embedded")
+			}
+		})
+	}
+}
+
+func TestPNGTextEntries(t *testing.T) {
+	data, err := embedPNGText(encodeTestImage(t, "png"), []textEntry{
+		{"prompt", "a café at dusk"},
+		{"sampler", ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := readPNGText(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text["prompt"] != "a café at dusk" {
+		t.Fatalf("got prompt %q", text["prompt"])
+	}
+	if _, ok := text["sampler"]; ok {
+		t.Fatal("empty entries should not be written")
+	}
+}
+
+func TestExtractMetadataMissing(t *testing.T) {
+	for _, ext := range []string{"png", "jpg"} {
+		if _, err := extractMetadata(encodeTestImage(t, ext)); err != errNoMetadata {
+			t.Fatalf("%s: got error %v, want %v", ext, err, errNoMetadata)
+		}
+	}
+}
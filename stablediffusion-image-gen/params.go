@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxSeed = 4294967295
+
+// allowedDimensions are the width x height combinations supported by SDXL.
+var allowedDimensions = [][2]int{
+	{1024, 1024},
+	{1152, 896},
+	{1216, 832},
+	{1344, 768},
+	{1536, 640},
+	{640, 1536},
+	{768, 1344},
+	{832, 1216},
+	{896, 1152},
+}
+
+var samplers = []string{
+	"DDIM", "DDPM", "K_DPMPP_2M", "K_DPMPP_2S_ANCESTRAL", "K_DPM_2",
+	"K_DPM_2_ANCESTRAL", "K_EULER", "K_EULER_ANCESTRAL", "K_HEUN", "K_LMS",
+}
+
+var stylePresets = []string{
+	"3d-model", "analog-film", "anime", "cinematic", "comic-book", "digital-art",
+	"enhance", "fantasy-art", "isometric", "line-art", "low-poly", "modeling-compound",
+	"neon-punk", "origami", "photographic", "pixel-art", "tile-texture",
+}
+
+var clipGuidancePresets = []string{
+	"FAST_BLUE", "FAST_GREEN", "NONE", "SIMPLE", "SLOW", "SLOWER", "SLOWEST",
+}
+
+var initImageModes = []string{"IMAGE_STRENGTH", "STEP_SCHEDULE"}
+
+var maskSources = []string{"MASK_IMAGE_WHITE", "MASK_IMAGE_BLACK", "INIT_IMAGE_ALPHA"}
+
+// Validate checks the request against the limits documented for SDXL.
+func (r Request) Validate() error {
+
+	if len(r.TextPrompts) == 0 {
+		return fmt.Errorf("at least one text prompt is required")
+	}
+
+	var positive bool
+	for _, p := range r.TextPrompts {
+		if p.Weight >= 0 {
+			positive = true
+		}
+	}
+	if !positive {
+		return fmt.Errorf("at least one text prompt must have a positive weight")
+	}
+
+	if r.Width != 0 || r.Height != 0 {
+		if !validDimensions(r.Width, r.Height) {
+			return fmt.Errorf("invalid dimensions %dx%d - allowed width x height are %s", r.Width, r.Height, formatDimensions())
+		}
+	}
+
+	if r.CfgScale < 0 || r.CfgScale > 35 {
+		return fmt.Errorf("cfg_scale must be between 0 and 35")
+	}
+
+	if r.Steps < 10 || r.Steps > 150 {
+		return fmt.Errorf("steps must be between 10 and 150")
+	}
+
+	if r.Seed < 0 || r.Seed > maxSeed {
+		return fmt.Errorf("seed must be between 0 and %d", maxSeed)
+	}
+
+	//Bedrock only supports a single sample per SDXL request
+	if r.Samples != 1 {
+		return fmt.Errorf("samples must be 1 for SDXL - generate more images with different seeds")
+	}
+
+	if r.Sampler != "" && !contains(samplers, r.Sampler) {
+		return fmt.Errorf("invalid sampler %q - must be one of %s", r.Sampler, strings.Join(samplers, ", "))
+	}
+
+	if r.StylePreset != "" && !contains(stylePresets, r.StylePreset) {
+		return fmt.Errorf("invalid style preset %q - must be one of %s", r.StylePreset, strings.Join(stylePresets, ", "))
+	}
+
+	if r.ClipGuidancePreset != "" && !contains(clipGuidancePresets, r.ClipGuidancePreset) {
+		return fmt.Errorf("invalid clip guidance preset %q - must be one of %s", r.ClipGuidancePreset, strings.Join(clipGuidancePresets, ", "))
+	}
+
+	if r.InitImageMode != "" {
+		if !contains(initImageModes, r.InitImageMode) {
+			return fmt.Errorf("invalid init image mode %q - must be one of %s", r.InitImageMode, strings.Join(initImageModes, ", "))
+		}
+
+		if r.ImageStrength < 0 || r.ImageStrength > 1 {
+			return fmt.Errorf("image_strength must be between 0 and 1")
+		}
+
+		if r.StepScheduleStart < 0 || r.StepScheduleStart > 1 || r.StepScheduleEnd < 0 || r.StepScheduleEnd > 1 {
+			return fmt.Errorf("step_schedule_start and step_schedule_end must be between 0 and 1")
+		}
+
+		if r.StepScheduleEnd != 0 && r.StepScheduleStart > r.StepScheduleEnd {
+			return fmt.Errorf("step_schedule_start must not be greater than step_schedule_end")
+		}
+	}
+
+	if r.MaskSource != "" {
+		if r.InitImageMode == "" {
+			return fmt.Errorf("mask_source requires an initial image")
+		}
+
+		if !contains(maskSources, r.MaskSource) {
+			return fmt.Errorf("invalid mask source %q - must be one of %s", r.MaskSource, strings.Join(maskSources, ", "))
+		}
+	}
+
+	return nil
+}
+
+func validDimensions(width, height int) bool {
+	for _, d := range allowedDimensions {
+		if d[0] == width && d[1] == height {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDimensions() string {
+	dims := make([]string, 0, len(allowedDimensions))
+	for _, d := range allowedDimensions {
+		dims = append(dims, fmt.Sprintf("%dx%d", d[0], d[1]))
+	}
+	return strings.Join(dims, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TextPrompts implements flag.Value so that (weighted) prompts can be passed
+// multiple times on the command line as text or text:weight.
+type TextPrompts []TextPrompt
+
+func (p TextPrompts) String() string {
+	parts := make([]string, 0, len(p))
+	for _, tp := range p {
+		parts = append(parts, fmt.Sprintf("%s:%g", tp.Text, tp.Weight))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *TextPrompts) Set(value string) error {
+	tp := TextPrompt{Text: value, Weight: 1}
+
+	if i := strings.LastIndex(value, ":"); i != -1 {
+		if w, err := strconv.ParseFloat(value[i+1:], 64); err == nil {
+			tp.Text, tp.Weight = value[:i], w
+		}
+	}
+
+	if strings.TrimSpace(tp.Text) == "" {
+		return fmt.Errorf("empty prompt")
+	}
+
+	*p = append(*p, tp)
+	return nil
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// sweepCell is one combination of the parameter sweep and its outcome.
+type sweepCell struct {
+	Seed        int64
+	CfgScale    float64
+	Steps       int
+	StylePreset string
+	File        string
+	Err         error
+}
+
+func (c sweepCell) labels() []string {
+	style := c.StylePreset
+	if style == "" {
+		style = "no style"
+	}
+	return []string{
+		fmt.Sprintf("seed %d | cfg %g", c.Seed, c.CfgScale),
+		fmt.Sprintf("steps %d | %s", c.Steps, style),
+	}
+}
+
+// sweep runs the cartesian product of seeds, cfg scales, step counts and style
+// presets with bounded concurrency, then composes the results into a
+// contact sheet and an HTML gallery.
+func sweep(args []string) {
+
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	rf := newRequestFlags(fs)
+	seeds := fs.String("seeds", "", "comma separated list of seeds (defaults to -seed)")
+	cfgScales := fs.String("cfg-scales", "", "comma separated list of cfg scales (defaults to -cfg-scale)")
+	stepCounts := fs.String("step-counts", "", "comma separated list of step counts (defaults to -steps)")
+	styles := fs.String("style-presets", "", "comma separated list of style presets, 'none' for no preset (defaults to -style-preset)")
+	concurrency := fs.Int("concurrency", 2, "maximum number of concurrent model invocations")
+	thumbnail := fs.Int("thumbnail", 256, "width of each image in the contact sheet")
+	columns := fs.Int("columns", 0, "number of columns in the contact sheet (defaults to a square grid)")
+	fs.Parse(args)
+
+	if *concurrency < 1 {
+		log.Fatal("concurrency must be at least 1")
+	}
+
+	if *thumbnail <= 0 {
+		log.Fatal("thumbnail width must be positive")
+	}
+
+	if fs.NArg() == 0 && len(rf.prompts) == 0 {
+		log.Fatal("usage: go run . sweep [flags] <prompt>")
+	}
+
+	if isTitanImageModel(*rf.modelID) {
+		log.Fatal("sweep is only supported for ", stableDiffusionXLModelID)
+	}
+
+	base, err := rf.request(fs.Arg(0))
+	if err != nil {
+		log.Fatal("invalid request: ", err)
+	}
+
+	seedList, err := parseList(*seeds, base.Seed, func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) })
+	if err != nil {
+		log.Fatal("invalid seeds: ", err)
+	}
+
+	cfgList, err := parseList(*cfgScales, base.CfgScale, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err != nil {
+		log.Fatal("invalid cfg scales: ", err)
+	}
+
+	stepList, err := parseList(*stepCounts, base.Steps, strconv.Atoi)
+	if err != nil {
+		log.Fatal("invalid step counts: ", err)
+	}
+
+	styleList, err := parseList(*styles, base.StylePreset, func(s string) (string, error) {
+		if s == "none" {
+			return "", nil
+		}
+		if !contains(stylePresets, s) {
+			return "", fmt.Errorf("unknown style preset %q", s)
+		}
+		return s, nil
+	})
+	if err != nil {
+		log.Fatal("invalid styles: ", err)
+	}
+
+	var cells []sweepCell
+	for _, style := range styleList {
+		for _, steps := range stepList {
+			for _, cfg := range cfgList {
+				for _, seed := range seedList {
+					cells = append(cells, sweepCell{Seed: seed, CfgScale: cfg, Steps: steps, StylePreset: style})
+				}
+			}
+		}
+	}
+
+	//validate every cell up front, so that an invalid combination fails before any invocation
+	payloads := make([]Request, len(cells))
+	for i, c := range cells {
+		payload := base
+		payload.Seed = c.Seed
+		payload.CfgScale = c.CfgScale
+		payload.Steps = c.Steps
+		payload.StylePreset = c.StylePreset
+		payload.Samples = 1
+
+		err := payload.Validate()
+		if err != nil {
+			log.Fatalf("invalid request (%s): %v", strings.Join(c.labels(), " | "), err)
+		}
+		payloads[i] = payload
+	}
+
+	dir := fmt.Sprintf("sweep-%d", time.Now().Unix())
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("generating %d images with concurrency %d\n", len(cells), *concurrency)
+
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+
+	for i, payload := range payloads {
+		wg.Add(1)
+		go func(i int, payload Request) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			files, err := generate(*rf.modelID, payload, filepath.Join(dir, fmt.Sprintf("%03d", i)))
+			if len(files) > 0 {
+				cells[i].File = files[0]
+			}
+			cells[i].Err = err
+
+			if err != nil {
+				log.Printf("[%d/%d] %s - %v", i+1, len(cells), strings.Join(cells[i].labels(), " | "), err)
+			} else {
+				log.Printf("[%d/%d] %s", i+1, len(cells), strings.Join(cells[i].labels(), " | "))
+			}
+		}(i, payload)
+	}
+
+	wg.Wait()
+
+	if *columns <= 0 {
+		*columns = int(math.Ceil(math.Sqrt(float64(len(cells)))))
+	}
+
+	sheet := filepath.Join(dir, "contact-sheet.png")
+	err = writeContactSheet(sheet, cells, *columns, *thumbnail)
+	if err != nil {
+		log.Fatal("failed to write contact sheet: ", err)
+	}
+	log.Println("contact sheet written to file", sheet)
+
+	gallery := filepath.Join(dir, "index.html")
+	err = writeGallery(gallery, dir, *rf.modelID, base, cells)
+	if err != nil {
+		log.Fatal("failed to write gallery: ", err)
+	}
+	log.Println("gallery written to file", gallery)
+}
+
+// parseList splits a comma separated list, returning def when it is empty.
+func parseList[T any](list string, def T, parse func(string) (T, error)) ([]T, error) {
+	if strings.TrimSpace(list) == "" {
+		return []T{def}, nil
+	}
+
+	var values []T
+	for _, s := range strings.Split(list, ",") {
+		v, err := parse(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+const (
+	labelLineHeight = 15
+	cellPadding     = 8
+)
+
+// writeContactSheet composes every generated image into a single labelled
+// grid. Combinations that failed are drawn as a grey cell with the error.
+func writeContactSheet(file string, cells []sweepCell, columns, thumbWidth int) error {
+
+	thumbs := make([]image.Image, len(cells))
+	thumbHeight := thumbWidth
+
+	for i, c := range cells {
+		if c.File == "" {
+			continue
+		}
+
+		img, err := decodeImageFile(c.File)
+		if err != nil {
+			return err
+		}
+
+		size := img.Bounds().Size()
+		h := thumbWidth * size.Y / size.X
+		thumbs[i] = resize(img, thumbWidth, h)
+		if h > thumbHeight {
+			thumbHeight = h
+		}
+	}
+
+	cellWidth := thumbWidth + 2*cellPadding
+	cellHeight := thumbHeight + 2*labelLineHeight + 2*cellPadding
+	rows := (len(cells) + columns - 1) / columns
+
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*cellWidth, rows*cellHeight))
+	draw.Draw(sheet, sheet.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for i, c := range cells {
+		origin := image.Pt((i%columns)*cellWidth+cellPadding, (i/columns)*cellHeight+cellPadding)
+		thumbRect := image.Rectangle{Min: origin, Max: origin.Add(image.Pt(thumbWidth, thumbHeight))}
+
+		labels := c.labels()
+		if thumbs[i] != nil {
+			draw.Draw(sheet, thumbRect, thumbs[i], image.Point{}, draw.Over)
+		} else {
+			draw.Draw(sheet, thumbRect, image.NewUniform(color.Gray{Y: 200}), image.Point{}, draw.Src)
+			drawLabel(sheet, origin.Add(image.Pt(4, thumbHeight/2)), errorLabel(c.Err))
+		}
+
+		for l, label := range labels {
+			drawLabel(sheet, origin.Add(image.Pt(0, thumbHeight+(l+1)*labelLineHeight)), label)
+		}
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return png.Encode(f, sheet)
+}
+
+func drawLabel(dst *image.RGBA, pt image.Point, text string) {
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Black,
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(pt.X, pt.Y),
+	}
+	d.DrawString(text)
+}
+
+func errorLabel(err error) string {
+	if artifactErrs, ok := err.(ArtifactErrors); ok && len(artifactErrs) > 0 {
+		return artifactErrs[0].FinishReason
+	}
+	return "failed"
+}
+
+func decodeImageFile(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Parameter sweep</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(300px, 1fr)); gap: 1em; }
+.cell { border: 1px solid #ddd; padding: 0.5em; }
+.cell img { width: 100%; }
+.error { background: #eee; padding: 2em; text-align: center; color: #a00; }
+</style>
+</head>
+<body>
+<h1>Parameter sweep</h1>
+<p>Prompt: {{.Prompt}}</p>
+{{if .NegativePrompt}}<p>Negative prompt: {{.NegativePrompt}}</p>{{end}}
+<p><a href="contact-sheet.png">Contact sheet</a></p>
+<div class="grid">
+{{range .Cells}}
+<div class="cell">
+{{if .File}}<a href="{{.File}}"><img src="{{.File}}"></a>{{else}}<div class="error">{{.Error}}</div>{{end}}
+{{range .Labels}}<div>{{.}}</div>{{end}}
+</div>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+func writeGallery(file, dir, modelID string, base Request, cells []sweepCell) error {
+
+	type galleryCell struct {
+		File   string
+		Error  string
+		Labels []string
+	}
+
+	meta := newMetadata(modelID, base)
+	data := struct {
+		Prompt, NegativePrompt string
+		Cells                  []galleryCell
+	}{Prompt: meta.Prompt, NegativePrompt: meta.NegativePrompt}
+
+	for _, c := range cells {
+		gc := galleryCell{Labels: c.labels()}
+		if c.File != "" {
+			gc.File, _ = filepath.Rel(dir, c.File)
+		}
+		if c.Err != nil {
+			gc.Error = c.Err.Error()
+		}
+		data.Cells = append(data.Cells, gc)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return galleryTemplate.Execute(f, data)
+}
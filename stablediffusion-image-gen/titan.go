@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	titanImageGeneratorModelID = "amazon.titan-image-generator-v1" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+
+	titanMaxSeed = 2147483646
+)
+
+// Titan Image Generator task types
+const (
+	TaskTextImage      = "TEXT_IMAGE"
+	TaskInpainting     = "INPAINTING"
+	TaskOutpainting    = "OUTPAINTING"
+	TaskImageVariation = "IMAGE_VARIATION"
+)
+
+var titanTaskTypes = []string{TaskTextImage, TaskInpainting, TaskOutpainting, TaskImageVariation}
+
+// titanDimensions are the width x height combinations supported by Titan Image Generator.
+var titanDimensions = [][2]int{
+	{1024, 1024}, {768, 768}, {512, 512},
+	{768, 1152}, {384, 576}, {1152, 768}, {576, 384},
+	{768, 1280}, {384, 640}, {1280, 768}, {640, 384},
+	{896, 1152}, {448, 576}, {1152, 896}, {576, 448},
+	{768, 1408}, {384, 704}, {1408, 768}, {704, 384},
+	{640, 1408}, {320, 704}, {1408, 640}, {704, 320},
+	{1152, 640}, {1173, 640},
+}
+
+func isTitanImageModel(modelID string) bool {
+	return strings.HasPrefix(modelID, "amazon.titan-image-generator")
+}
+
+// titanRequest builds a Titan Image Generator request from the same flags as
+// the SDXL request. Negative prompts are joined into negativeText, and the
+// initial image (and mask image) are used as input for the image editing
+// task types.
+func (f *requestFlags) titanRequest(prompt string) (TitanImageRequest, error) {
+
+	var texts, negativeTexts []string
+	if prompt != "" {
+		texts = append(texts, prompt)
+	}
+	for _, p := range f.prompts {
+		texts = append(texts, p.Text)
+	}
+	for _, p := range f.negativePrompts {
+		negativeTexts = append(negativeTexts, p.Text)
+	}
+
+	text, negativeText := strings.Join(texts, ", "), strings.Join(negativeTexts, ", ")
+
+	seed := *f.seed
+	if seed < 0 {
+		seed = randomSeed() % (titanMaxSeed + 1)
+	}
+
+	payload := TitanImageRequest{
+		TaskType: *f.taskType,
+		ImageGenerationConfig: &ImageGenerationConfig{
+			NumberOfImages: *f.samples,
+			Quality:        *f.quality,
+			Height:         *f.height,
+			Width:          *f.width,
+			CfgScale:       *f.cfgScale,
+			Seed:           seed,
+		},
+	}
+
+	err := payload.ImageGenerationConfig.Validate()
+	if err != nil {
+		return payload, err
+	}
+
+	var image, mask string
+	if payload.TaskType != TaskTextImage {
+		if *f.initImage == "" {
+			return payload, fmt.Errorf("task type %s requires init-image", payload.TaskType)
+		}
+
+		image, err = loadInputImage(*f.initImage, *f.width, *f.height, *f.fit)
+		if err != nil {
+			return payload, fmt.Errorf("failed to load input image: %w", err)
+		}
+
+		if *f.maskImage != "" {
+			mask, err = loadInputImage(*f.maskImage, *f.width, *f.height, *f.fit)
+			if err != nil {
+				return payload, fmt.Errorf("failed to load mask image: %w", err)
+			}
+		}
+	}
+
+	switch payload.TaskType {
+	case TaskTextImage:
+		payload.TextToImageParams = &TextToImageParams{Text: text, NegativeText: negativeText}
+	case TaskInpainting:
+		payload.InPaintingParams = &InPaintingParams{
+			Image: image, Text: text, NegativeText: negativeText, MaskPrompt: *f.maskPrompt, MaskImage: mask,
+		}
+	case TaskOutpainting:
+		payload.OutPaintingParams = &OutPaintingParams{
+			Image: image, Text: text, NegativeText: negativeText, MaskPrompt: *f.maskPrompt, MaskImage: mask, OutPaintingMode: *f.outPaintingMode,
+		}
+	case TaskImageVariation:
+		payload.ImageVariationParams = &ImageVariationParams{Images: []string{image}, Text: text, NegativeText: negativeText}
+	default:
+		return payload, fmt.Errorf("invalid task type %q - must be one of %s", payload.TaskType, strings.Join(titanTaskTypes, ", "))
+	}
+
+	if (payload.TaskType == TaskInpainting || payload.TaskType == TaskOutpainting) && (*f.maskPrompt == "") == (mask == "") {
+		return payload, fmt.Errorf("task type %s requires exactly one of mask-prompt or mask-image", payload.TaskType)
+	}
+
+	if payload.TaskType != TaskImageVariation && text == "" {
+		return payload, fmt.Errorf("a prompt is required for task type %s", payload.TaskType)
+	}
+
+	return payload, nil
+}
+
+// Validate checks the configuration against the limits documented for Titan Image Generator.
+func (c *ImageGenerationConfig) Validate() error {
+
+	if c.NumberOfImages < 1 || c.NumberOfImages > 5 {
+		return fmt.Errorf("number of images must be between 1 and 5")
+	}
+
+	if c.Quality != "standard" && c.Quality != "premium" {
+		return fmt.Errorf("invalid quality %q - must be standard or premium", c.Quality)
+	}
+
+	var valid bool
+	for _, d := range titanDimensions {
+		if d[0] == c.Width && d[1] == c.Height {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid dimensions %dx%d for Titan Image Generator", c.Width, c.Height)
+	}
+
+	if c.CfgScale < 1.1 || c.CfgScale > 10 {
+		return fmt.Errorf("cfg scale must be between 1.1 and 10")
+	}
+
+	if c.Seed < 0 || c.Seed > titanMaxSeed {
+		return fmt.Errorf("seed must be between 0 and %d", titanMaxSeed)
+	}
+
+	return nil
+}
+
+// generateTitan invokes Titan Image Generator and writes the images through
+// the same artifact path as SDXL.
+func generateTitan(modelID string, payload TitanImageRequest, prefix string) ([]string, error) {
+
+	var resp TitanImageResponse
+
+	err := invoke(modelID, payload, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("image generation failed: %s", resp.Error)
+	}
+
+	return writeArtifacts(resp.artifacts(), prefix, newTitanMetadata(modelID, payload))
+}
+
+//request/response model (Titan Image Generator)
+
+type TitanImageRequest struct {
+	TaskType              string                 `json:"taskType"`
+	TextToImageParams     *TextToImageParams     `json:"textToImageParams,omitempty"`
+	InPaintingParams      *InPaintingParams      `json:"inPaintingParams,omitempty"`
+	OutPaintingParams     *OutPaintingParams     `json:"outPaintingParams,omitempty"`
+	ImageVariationParams  *ImageVariationParams  `json:"imageVariationParams,omitempty"`
+	ImageGenerationConfig *ImageGenerationConfig `json:"imageGenerationConfig,omitempty"`
+}
+
+type TextToImageParams struct {
+	Text         string `json:"text"`
+	NegativeText string `json:"negativeText,omitempty"`
+}
+
+type InPaintingParams struct {
+	Image        string `json:"image"`
+	Text         string `json:"text,omitempty"`
+	NegativeText string `json:"negativeText,omitempty"`
+	MaskPrompt   string `json:"maskPrompt,omitempty"`
+	MaskImage    string `json:"maskImage,omitempty"`
+}
+
+type OutPaintingParams struct {
+	Image           string `json:"image"`
+	Text            string `json:"text"`
+	NegativeText    string `json:"negativeText,omitempty"`
+	MaskPrompt      string `json:"maskPrompt,omitempty"`
+	MaskImage       string `json:"maskImage,omitempty"`
+	OutPaintingMode string `json:"outPaintingMode,omitempty"`
+}
+
+type ImageVariationParams struct {
+	Images       []string `json:"images"`
+	Text         string   `json:"text,omitempty"`
+	NegativeText string   `json:"negativeText,omitempty"`
+}
+
+type ImageGenerationConfig struct {
+	NumberOfImages int     `json:"numberOfImages"`
+	Quality        string  `json:"quality"`
+	Height         int     `json:"height"`
+	Width          int     `json:"width"`
+	CfgScale       float64 `json:"cfgScale"`
+	Seed           int64   `json:"seed"`
+}
+
+type TitanImageResponse struct {
+	Images []string `json:"images"`
+	Error  string   `json:"error"`
+}
+
+// artifacts converts the images to SDXL style artifacts. Titan fails the
+// whole request instead of flagging individual images, so they all succeeded.
+func (r TitanImageResponse) artifacts() []Artifact {
+	artifacts := make([]Artifact, 0, len(r.Images))
+	for _, img := range r.Images {
+		artifacts = append(artifacts, Artifact{Base64: img, FinishReason: FinishReasonSuccess})
+	}
+	return artifacts
+}
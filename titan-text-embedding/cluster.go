@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const (
+	claudePromptFormat = "\n\nHuman:%s\n\nAssistant:"
+	claudeV2ModelID    = "anthropic.claude-v2" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+)
+
+const clusterLabelPrompt = `<examples>
+%s
+</examples>
+
+The above examples are support tickets that were grouped together because they are about the same topic. Please write a short title (no more than six words) describing the topic.
+
+Please output the title in <title></title> tags.`
+
+// cluster embeds a corpus, reports near-duplicate pairs and groups the corpus
+// into clusters with either k-means or agglomerative clustering.
+func cluster(args []string) {
+
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	file := fs.String("file", "samples.txt", "file with one document per line")
+	modelID := fs.String("model", titanEmbeddingModelID, "embedding model ID")
+	dimensions := fs.Int("dimensions", 0, "number of output dimensions - 256, 512 or 1024 (Titan Embeddings V2 only)")
+	truncate := fs.String("truncate", "", "how to truncate long documents - NONE, START or END (Cohere Embed only)")
+	threshold := fs.Float64("threshold", 0.9, "cosine similarity above which two documents are reported as near-duplicates")
+	algorithm := fs.String("algorithm", "kmeans", "clustering algorithm - kmeans or agglomerative")
+	k := fs.Int("k", 5, "number of clusters")
+	examples := fs.Int("examples", 3, "number of representative examples per cluster")
+	seed := fs.Int64("seed", 1, "random seed used for k-means initialization")
+	label := fs.Bool("label", false, "ask Claude to label each cluster with a short title")
+	out := fs.String("out", "clusters.json", "file to write cluster assignments to")
+	fs.Parse(args)
+
+	if *k < 1 {
+		log.Fatal("k must be at least 1")
+	}
+
+	docs, err := readLines(*file)
+	if err != nil {
+		log.Fatal("failed to read corpus: ", err)
+	}
+
+	if len(docs) == 0 {
+		log.Fatal("corpus is empty")
+	}
+
+	if *k > len(docs) {
+		fmt.Printf("only %d documents - using k=%d\n", len(docs), len(docs))
+		*k = len(docs)
+	}
+
+	fmt.Printf("embedding %d documents\n", len(docs))
+
+	opts := EmbeddingOptions{
+		ModelID:    *modelID,
+		Dimensions: *dimensions,
+		Normalize:  true,
+		InputType:  Clustering,
+		Truncate:   Truncate(*truncate),
+	}
+
+	vectors, err := embedAll(docs, opts)
+	if err != nil {
+		log.Fatal("failed to generate embeddings: ", err)
+	}
+
+	result := ClusterResult{Duplicates: findDuplicates(docs, vectors, *threshold)}
+
+	fmt.Printf("\nfound %d near-duplicate pairs (threshold %.2f)\n", len(result.Duplicates), *threshold)
+	for _, d := range result.Duplicates {
+		fmt.Printf("  %.3f  %q <-> %q\n", d.Similarity, d.TextA, d.TextB)
+	}
+
+	var assignments []int
+	switch *algorithm {
+	case "kmeans":
+		assignments = kmeans(vectors, *k, 100, rand.New(rand.NewSource(*seed)))
+	case "agglomerative":
+		assignments = agglomerative(vectors, *k)
+	default:
+		log.Fatal("invalid clustering algorithm: ", *algorithm)
+	}
+
+	result.Clusters = buildClusters(docs, vectors, assignments, *examples)
+
+	for i := range result.Clusters {
+		c := &result.Clusters[i]
+
+		if *label {
+			c.Title, err = labelCluster(c.Representatives)
+			if err != nil {
+				log.Fatal("failed to label cluster: ", err)
+			}
+		}
+
+		fmt.Printf("\ncluster %d (%d documents) %s\n", c.ID, len(c.Members), c.Title)
+		for _, r := range c.Representatives {
+			fmt.Println("  -", r)
+		}
+	}
+
+	resultBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(*out, resultBytes, 0644)
+	if err != nil {
+		log.Fatal("failed to write cluster assignments: ", err)
+	}
+
+	fmt.Println("\ncluster assignments written to file", *out)
+}
+
+type ClusterResult struct {
+	Duplicates []DuplicatePair `json:"duplicates"`
+	Clusters   []Cluster       `json:"clusters"`
+}
+
+type DuplicatePair struct {
+	A          int     `json:"a"`
+	B          int     `json:"b"`
+	TextA      string  `json:"text_a"`
+	TextB      string  `json:"text_b"`
+	Similarity float64 `json:"similarity"`
+}
+
+type Cluster struct {
+	ID              int      `json:"id"`
+	Title           string   `json:"title,omitempty"`
+	Members         []Member `json:"members"`
+	Representatives []string `json:"representatives"`
+}
+
+type Member struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+func findDuplicates(docs []string, vectors []Vector, threshold float64) []DuplicatePair {
+	var pairs []DuplicatePair
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			if sim := vectors[i].Cosine(vectors[j]); sim >= threshold {
+				pairs = append(pairs, DuplicatePair{A: i, B: j, TextA: docs[i], TextB: docs[j], Similarity: sim})
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].Similarity > pairs[b].Similarity })
+	return pairs
+}
+
+// kmeans clusters the vectors using cosine similarity, with k-means++
+// initialization. It returns the cluster index of every vector.
+func kmeans(vectors []Vector, k, maxIterations int, rnd *rand.Rand) []int {
+
+	centroids := []Vector{vectors[rnd.Intn(len(vectors))]}
+	for len(centroids) < k {
+		distances := make([]float64, len(vectors))
+		var total float64
+		for i, v := range vectors {
+			d := 1 - v.Cosine(centroids[nearest(v, centroids)])
+			distances[i] = d * d
+			total += distances[i]
+		}
+
+		if total == 0 {
+			centroids = append(centroids, vectors[rnd.Intn(len(vectors))])
+			continue
+		}
+
+		target := rnd.Float64() * total
+		for i, d := range distances {
+			target -= d
+			if target <= 0 {
+				centroids = append(centroids, vectors[i])
+				break
+			}
+		}
+	}
+
+	assignments := make([]int, len(vectors))
+	for iter := 0; iter < maxIterations; iter++ {
+		changed := false
+		for i, v := range vectors {
+			if c := nearest(v, centroids); c != assignments[i] {
+				assignments[i] = c
+				changed = true
+			}
+		}
+
+		if !changed && iter > 0 {
+			break
+		}
+
+		for c := range centroids {
+			if m := mean(vectors, assignments, c); m != nil {
+				centroids[c] = m
+			}
+		}
+	}
+
+	return assignments
+}
+
+func nearest(v Vector, centroids []Vector) int {
+	best, bestSim := 0, math.Inf(-1)
+	for c, centroid := range centroids {
+		if sim := v.Cosine(centroid); sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	return best
+}
+
+func mean(vectors []Vector, assignments []int, cluster int) Vector {
+	var m Vector
+	var n int
+	for i, v := range vectors {
+		if assignments[i] != cluster {
+			continue
+		}
+		if m == nil {
+			m = make(Vector, len(v))
+		}
+		for d := range v {
+			m[d] += v[d]
+		}
+		n++
+	}
+	for d := range m {
+		m[d] /= float32(n)
+	}
+	return m
+}
+
+// agglomerative performs average-linkage hierarchical clustering, merging the
+// two most similar clusters until k clusters remain.
+func agglomerative(vectors []Vector, k int) []int {
+
+	n := len(vectors)
+	sim := make([][]float64, n)
+	for i := range sim {
+		sim[i] = make([]float64, n)
+		for j := range sim[i] {
+			sim[i][j] = vectors[i].Cosine(vectors[j])
+		}
+	}
+
+	sizes := make([]int, n)
+	active := make([]bool, n)
+	assignments := make([]int, n)
+	for i := range vectors {
+		sizes[i] = 1
+		active[i] = true
+		assignments[i] = i
+	}
+
+	for remaining := n; remaining > k; remaining-- {
+		a, b, best := -1, -1, math.Inf(-1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && sim[i][j] > best {
+					a, b, best = i, j, sim[i][j]
+				}
+			}
+		}
+
+		//merge b into a, updating the average linkage to every other cluster
+		for c := 0; c < n; c++ {
+			if active[c] && c != a && c != b {
+				s := (float64(sizes[a])*sim[a][c] + float64(sizes[b])*sim[b][c]) / float64(sizes[a]+sizes[b])
+				sim[a][c], sim[c][a] = s, s
+			}
+		}
+		sizes[a] += sizes[b]
+		active[b] = false
+		for i := range assignments {
+			if assignments[i] == b {
+				assignments[i] = a
+			}
+		}
+	}
+
+	//renumber clusters from 0 to k-1
+	ids := map[int]int{}
+	for i, c := range assignments {
+		if _, ok := ids[c]; !ok {
+			ids[c] = len(ids)
+		}
+		assignments[i] = ids[c]
+	}
+
+	return assignments
+}
+
+// buildClusters groups documents by assignment and picks the documents closest
+// to each cluster centroid as representative examples.
+func buildClusters(docs []string, vectors []Vector, assignments []int, examples int) []Cluster {
+
+	var max int
+	for _, c := range assignments {
+		if c > max {
+			max = c
+		}
+	}
+
+	var clusters []Cluster
+	for c := 0; c <= max; c++ {
+		centroid := mean(vectors, assignments, c)
+		if centroid == nil {
+			continue //k-means can leave a cluster empty
+		}
+
+		cluster := Cluster{ID: len(clusters)}
+		for i := range docs {
+			if assignments[i] == c {
+				cluster.Members = append(cluster.Members, Member{Index: i, Text: docs[i]})
+			}
+		}
+
+		ranked := make([]Member, len(cluster.Members))
+		copy(ranked, cluster.Members)
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return vectors[ranked[a].Index].Cosine(centroid) > vectors[ranked[b].Index].Cosine(centroid)
+		})
+
+		for i := 0; i < len(ranked) && i < examples; i++ {
+			cluster.Representatives = append(cluster.Representatives, ranked[i].Text)
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	sort.SliceStable(clusters, func(a, b int) bool { return len(clusters[a].Members) > len(clusters[b].Members) })
+	return clusters
+}
+
+func labelCluster(representatives []string) (string, error) {
+
+	payload := ClaudeRequest{
+		Prompt:            fmt.Sprintf(claudePromptFormat, fmt.Sprintf(clusterLabelPrompt, strings.Join(representatives, "\n"))),
+		MaxTokensToSample: 100,
+		Temperature:       0.2,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
+		Body:        payloadBytes,
+		ModelId:     aws.String(claudeV2ModelID),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	var resp ClaudeResponse
+
+	err = json.Unmarshal(output.Body, &resp)
+
+	if err != nil {
+		return "", err
+	}
+
+	title := resp.Completion
+	if start := strings.Index(title, "<title>"); start != -1 {
+		title = title[start+len("<title>"):]
+	}
+	if end := strings.Index(title, "</title>"); end != -1 {
+		title = title[:end]
+	}
+
+	return strings.TrimSpace(title), nil
+}
+
+//request/response model (Claude)
+
+type ClaudeRequest struct {
+	Prompt            string   `json:"prompt"`
+	MaxTokensToSample int      `json:"max_tokens_to_sample"`
+	Temperature       float64  `json:"temperature,omitempty"`
+	TopP              float64  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Completion string `json:"completion"`
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+const (
+	cohereEmbedEnglishModelID      = "cohere.embed-english-v3"      //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+	cohereEmbedMultilingualModelID = "cohere.embed-multilingual-v3" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+
+	cohereMaxBatchSize  = 96
+	cohereMaxTextLength = 2048
+)
+
+type InputType string
+
+const (
+	SearchDocument InputType = "search_document"
+	SearchQuery    InputType = "search_query"
+	Classification InputType = "classification"
+	Clustering     InputType = "clustering"
+)
+
+type Truncate string
+
+const (
+	TruncateNone  Truncate = "NONE"
+	TruncateStart Truncate = "START"
+	TruncateEnd   Truncate = "END"
+)
+
+func isCohereEmbedModel(modelID string) bool {
+	return strings.HasPrefix(modelID, "cohere.embed")
+}
+
+func validateCohereOptions(opts EmbeddingOptions) error {
+	switch opts.InputType {
+	case SearchDocument, SearchQuery, Classification, Clustering:
+	default:
+		return fmt.Errorf("invalid input type %q - must be search_document, search_query, classification or clustering", opts.InputType)
+	}
+
+	switch opts.Truncate {
+	case "", TruncateNone, TruncateStart, TruncateEnd:
+	default:
+		return fmt.Errorf("invalid truncate option %q - must be NONE, START or END", opts.Truncate)
+	}
+
+	return nil
+}
+
+// embedCohere sends the texts in batches of up to cohereMaxBatchSize and
+// returns one vector per text, in the same order.
+func embedCohere(ctx context.Context, texts []string, opts EmbeddingOptions) ([]Vector, error) {
+
+	err := validateCohereOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, text := range texts {
+		if len(text) > cohereMaxTextLength && (opts.Truncate == "" || opts.Truncate == TruncateNone) {
+			return nil, fmt.Errorf("text %d is longer than %d characters - use a truncate option", i, cohereMaxTextLength)
+		}
+	}
+
+	vectors := make([]Vector, 0, len(texts))
+
+	for start := 0; start < len(texts); start += cohereMaxBatchSize {
+		end := start + cohereMaxBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		payload := CohereRequest{
+			Texts:     texts[start:end],
+			InputType: opts.InputType,
+			Truncate:  opts.Truncate,
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			Body:        payloadBytes,
+			ModelId:     aws.String(opts.ModelID),
+			ContentType: aws.String("application/json"),
+			Accept:      aws.String("*/*"),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		var resp CohereResponse
+
+		err = json.Unmarshal(output.Body, &resp)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Embeddings))
+		}
+
+		vectors = append(vectors, resp.Embeddings...)
+	}
+
+	return vectors, nil
+}
+
+//request/response model (Cohere)
+
+type CohereRequest struct {
+	Texts     []string  `json:"texts"`
+	InputType InputType `json:"input_type"`
+	Truncate  Truncate  `json:"truncate,omitempty"`
+}
+
+type CohereResponse struct {
+	Embeddings   []Vector `json:"embeddings"`
+	ID           string   `json:"id"`
+	ResponseType string   `json:"response_type"`
+	Texts        []string `json:"texts"`
+}
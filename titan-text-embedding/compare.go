@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// similarityFunc returns the similarity between items i and j of the sample set.
+type similarityFunc func(i, j int) float64
+
+type variant struct {
+	name  string
+	bytes int
+	sim   similarityFunc
+}
+
+// compare embeds a sample set at full precision (1024 dimensions, float32) and
+// reports the recall@k of reduced dimensions, quantized vectors and other
+// embedding models against it.
+func compare(args []string) {
+
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	file := fs.String("file", "samples.txt", "file with one sample text per line")
+	k := fs.Int("k", 5, "number of nearest neighbours used to compute recall")
+	dims := fs.String("dimensions", "256,512", "comma separated list of reduced dimensions to compare")
+	against := fs.String("against", "", "comma separated list of other embedding models (e.g. cohere.embed-english-v3) to compare")
+	fs.Parse(args)
+
+	if *k < 1 {
+		log.Fatal("k must be at least 1")
+	}
+
+	samples, err := readLines(*file)
+	if err != nil {
+		log.Fatal("failed to read samples: ", err)
+	}
+
+	if len(samples) < 2 {
+		log.Fatal("need at least two samples to compare")
+	}
+
+	if *k >= len(samples) {
+		*k = len(samples) - 1
+	}
+
+	fmt.Printf("embedding %d samples at full precision\n", len(samples))
+
+	full, err := embedAll(samples, EmbeddingOptions{ModelID: titanEmbeddingV2ModelID, Dimensions: 1024, Normalize: true})
+	if err != nil {
+		log.Fatal("failed to generate embeddings: ", err)
+	}
+
+	baseline := func(i, j int) float64 { return full[i].Cosine(full[j]) }
+
+	int8s := make([]Int8Vector, len(full))
+	binaries := make([]BinaryVector, len(full))
+	for i, v := range full {
+		int8s[i] = QuantizeInt8(v)
+		binaries[i] = QuantizeBinary(v)
+	}
+
+	variants := []variant{
+		{name: "float32 x 1024", bytes: storageSize(full[0], "none"), sim: baseline},
+		{name: "int8 x 1024", bytes: storageSize(full[0], "int8"), sim: func(i, j int) float64 { return int8s[i].Cosine(int8s[j]) }},
+		{name: "binary x 1024", bytes: storageSize(full[0], "binary"), sim: func(i, j int) float64 { return binaries[i].Similarity(binaries[j], len(full[i])) }},
+	}
+
+	for _, d := range strings.Split(*dims, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			log.Fatal("invalid dimensions: ", d)
+		}
+
+		fmt.Printf("embedding %d samples with %d dimensions\n", len(samples), n)
+
+		reduced, err := embedAll(samples, EmbeddingOptions{ModelID: titanEmbeddingV2ModelID, Dimensions: n, Normalize: true})
+		if err != nil {
+			log.Fatal("failed to generate embeddings: ", err)
+		}
+
+		variants = append(variants, variant{
+			name:  fmt.Sprintf("float32 x %d", n),
+			bytes: storageSize(reduced[0], "none"),
+			sim:   func(i, j int) float64 { return reduced[i].Cosine(reduced[j]) },
+		})
+	}
+
+	for _, m := range strings.Split(*against, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+
+		fmt.Printf("embedding %d samples with %s\n", len(samples), m)
+
+		other, err := embedAll(samples, EmbeddingOptions{ModelID: m, InputType: SearchDocument})
+		if err != nil {
+			log.Fatal("failed to generate embeddings: ", err)
+		}
+
+		variants = append(variants, variant{
+			name:  fmt.Sprintf("%s x %d", m, len(other[0])),
+			bytes: storageSize(other[0], "none"),
+			sim:   func(i, j int) float64 { return other[i].Cosine(other[j]) },
+		})
+	}
+
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "variant\tbytes/vector\trecall@%d\n", *k)
+	for _, v := range variants {
+		fmt.Fprintf(w, "%s\t%d\t%.3f\n", v.name, v.bytes, recallAtK(len(samples), *k, baseline, v.sim))
+	}
+	w.Flush()
+}
+
+// recallAtK uses every sample as a query against the rest of the set and
+// returns the average fraction of the baseline top-k neighbours that are also
+// in the candidate top-k.
+func recallAtK(n, k int, baseline, candidate similarityFunc) float64 {
+	var total float64
+	for q := 0; q < n; q++ {
+		expected := topK(n, k, q, baseline)
+		actual := topK(n, k, q, candidate)
+
+		var hits int
+		for idx := range actual {
+			if expected[idx] {
+				hits++
+			}
+		}
+		total += float64(hits) / float64(k)
+	}
+	return total / float64(n)
+}
+
+func topK(n, k, query int, sim similarityFunc) map[int]bool {
+	candidates := make([]int, 0, n-1)
+	for i := 0; i < n; i++ {
+		if i != query {
+			candidates = append(candidates, i)
+		}
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return sim(query, candidates[a]) > sim(query, candidates[b])
+	})
+
+	result := make(map[int]bool, k)
+	for _, idx := range candidates[:k] {
+		result[idx] = true
+	}
+	return result
+}
+
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
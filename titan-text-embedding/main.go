@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -15,10 +16,13 @@ import (
 const defaultRegion = "us-east-1"
 
 const (
-	titanEmbeddingModelID = "amazon.titan-embed-text-v1" //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+	titanEmbeddingModelID   = "amazon.titan-embed-text-v1"   //https://docs.aws.amazon.com/bedrock/latest/userguide/model-ids-arns.html
+	titanEmbeddingV2ModelID = "amazon.titan-embed-text-v2:0" //supports dimensions and normalize
 )
 
-func main() {
+var brc *bedrockruntime.Client
+
+func init() {
 
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -30,27 +34,149 @@ func main() {
 		log.Fatal(err)
 	}
 
-	brc := bedrockruntime.NewFromConfig(cfg)
+	brc = bedrockruntime.NewFromConfig(cfg)
+}
+
+func main() {
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			compare(os.Args[2:])
+			return
+		case "cluster":
+			cluster(os.Args[2:])
+			return
+		}
+	}
+
+	modelID := flag.String("model", titanEmbeddingModelID, "embedding model ID")
+	dimensions := flag.Int("dimensions", 0, "number of output dimensions - 256, 512 or 1024 (Titan Embeddings V2 only)")
+	normalize := flag.Bool("normalize", true, "normalize the output vector (Titan Embeddings V2 only)")
+	inputType := flag.String("input-type", string(SearchDocument), "input type - search_document, search_query, classification or clustering (Cohere Embed only)")
+	truncate := flag.String("truncate", "", "how to truncate long input - NONE, START or END (Cohere Embed only)")
+	quantize := flag.String("quantize", "none", "quantize the vector for compact storage - none, int8 or binary")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: go run . [flags] <text>")
+	}
+
+	input := flag.Arg(0)
 
-	input := os.Args[1]
+	opts := EmbeddingOptions{
+		ModelID:    *modelID,
+		Dimensions: *dimensions,
+		Normalize:  *normalize,
+		InputType:  InputType(*inputType),
+		Truncate:   Truncate(*truncate),
+	}
+
+	vectors, err := embedAll([]string{input}, opts)
+	if err != nil {
+		log.Fatal("failed to generate embedding: ", err)
+	}
+
+	embedding := vectors[0]
+
+	switch *quantize {
+	case "none":
+		fmt.Println("embedding vector from LLM\n", embedding)
+	case "int8":
+		q := QuantizeInt8(embedding)
+		fmt.Println("int8 quantized embedding vector\n", q.Values)
+		fmt.Println("quantization scale -", q.Scale)
+	case "binary":
+		fmt.Printf("binary quantized embedding vector\n %08b\n", QuantizeBinary(embedding))
+	default:
+		log.Fatal("invalid quantization type: ", *quantize)
+	}
+	fmt.Println()
+
+	fmt.Println("generated embedding for input -", input)
+	fmt.Println("generated vector length -", len(embedding))
+	fmt.Println("storage size (bytes) -", storageSize(embedding, *quantize))
+}
+
+// EmbeddingOptions controls how the embedding request is built.
+// Dimensions and Normalize are only sent for Titan Embeddings V2,
+// InputType and Truncate only for Cohere Embed.
+type EmbeddingOptions struct {
+	ModelID    string
+	Dimensions int
+	Normalize  bool
+	InputType  InputType
+	Truncate   Truncate
+}
+
+// embedAll returns one vector per text regardless of the model, so that Titan
+// and Cohere embeddings can back the same index. Cohere models are invoked in
+// batches, Titan models one text at a time.
+func embedAll(texts []string, opts EmbeddingOptions) ([]Vector, error) {
+
+	if isCohereEmbedModel(opts.ModelID) {
+		if opts.Dimensions != 0 {
+			return nil, fmt.Errorf("dimensions is only supported by %s", titanEmbeddingV2ModelID)
+		}
+		return embedCohere(context.Background(), texts, opts)
+	}
+
+	vectors := make([]Vector, 0, len(texts))
+	for _, text := range texts {
+		resp, err := embed(context.Background(), text, opts)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, resp.Embedding)
+	}
+	return vectors, nil
+}
+
+func (o EmbeddingOptions) validate() error {
+	if o.ModelID != titanEmbeddingV2ModelID {
+		if o.Dimensions != 0 {
+			return fmt.Errorf("dimensions is only supported by %s", titanEmbeddingV2ModelID)
+		}
+		return nil
+	}
+
+	switch o.Dimensions {
+	case 0, 256, 512, 1024:
+		return nil
+	default:
+		return fmt.Errorf("invalid dimensions %d - must be 256, 512 or 1024", o.Dimensions)
+	}
+}
+
+func embed(ctx context.Context, input string, opts EmbeddingOptions) (Response, error) {
+
+	err := opts.validate()
+	if err != nil {
+		return Response{}, err
+	}
 
 	payload := Request{
 		InputText: input,
 	}
 
+	if opts.ModelID == titanEmbeddingV2ModelID {
+		payload.Dimensions = opts.Dimensions
+		payload.Normalize = aws.Bool(opts.Normalize)
+	}
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Fatal(err)
+		return Response{}, err
 	}
 
-	output, err := brc.InvokeModel(context.Background(), &bedrockruntime.InvokeModelInput{
+	output, err := brc.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
 		Body:        payloadBytes,
-		ModelId:     aws.String(titanEmbeddingModelID),
+		ModelId:     aws.String(opts.ModelID),
 		ContentType: aws.String("application/json"),
 	})
 
 	if err != nil {
-		log.Fatal("failed to invoke model: ", err)
+		return Response{}, err
 	}
 
 	var resp Response
@@ -58,23 +184,32 @@ func main() {
 	err = json.Unmarshal(output.Body, &resp)
 
 	if err != nil {
-		log.Fatal("failed to unmarshal", err)
+		return Response{}, err
 	}
 
-	fmt.Println("embedding vector from LLM\n", resp.Embedding)
-	fmt.Println()
+	return resp, nil
+}
 
-	fmt.Println("generated embedding for input -", input)
-	fmt.Println("generated vector length -", len(resp.Embedding))
+func storageSize(v Vector, quantize string) int {
+	switch quantize {
+	case "int8":
+		return len(v) + 4 //one byte per dimension plus a float32 scale
+	case "binary":
+		return (len(v) + 7) / 8
+	default:
+		return len(v) * 4
+	}
 }
 
 //request/response model
 
 type Request struct {
-	InputText string `json:"inputText"`
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"`
+	Normalize  *bool  `json:"normalize,omitempty"`
 }
 
 type Response struct {
-	Embedding           []float64 `json:"embedding"`
-	InputTextTokenCount int       `json:"inputTextTokenCount"`
+	Embedding           Vector `json:"embedding"`
+	InputTextTokenCount int    `json:"inputTextTokenCount"`
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Vector is an embedding stored with float32 precision, which is what the
+// models return and halves the memory footprint compared to float64.
+type Vector []float32
+
+// Cosine returns the cosine similarity between two vectors of equal length.
+func (v Vector) Cosine(other Vector) float64 {
+	var dot, normA, normB float64
+	for i := range v {
+		a, b := float64(v[i]), float64(other[i])
+		dot += a * b
+		normA += a * a
+		normB += b * b
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Int8Vector is a vector quantized to one signed byte per dimension using a
+// symmetric per-vector scale.
+type Int8Vector struct {
+	Values []int8
+	Scale  float32
+}
+
+// QuantizeInt8 maps each dimension to [-127, 127] relative to the largest
+// absolute value in the vector.
+func QuantizeInt8(v Vector) Int8Vector {
+	var max float32
+	for _, x := range v {
+		if a := float32(math.Abs(float64(x))); a > max {
+			max = a
+		}
+	}
+
+	q := Int8Vector{Values: make([]int8, len(v))}
+	if max == 0 {
+		return q
+	}
+
+	q.Scale = max / 127
+	for i, x := range v {
+		q.Values[i] = int8(math.Round(float64(x / q.Scale)))
+	}
+	return q
+}
+
+// Dequantize converts the vector back to (approximate) float32 values.
+func (q Int8Vector) Dequantize() Vector {
+	v := make(Vector, len(q.Values))
+	for i, x := range q.Values {
+		v[i] = float32(x) * q.Scale
+	}
+	return v
+}
+
+// Cosine returns the cosine similarity computed on the int8 values. The scale
+// cancels out, so it does not need to be applied.
+func (q Int8Vector) Cosine(other Int8Vector) float64 {
+	var dot, normA, normB int64
+	for i := range q.Values {
+		a, b := int64(q.Values[i]), int64(other.Values[i])
+		dot += a * b
+		normA += a * a
+		normB += b * b
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float64(dot) / (math.Sqrt(float64(normA)) * math.Sqrt(float64(normB)))
+}
+
+// BinaryVector stores one bit per dimension (set when the value is positive),
+// packed most significant bit first.
+type BinaryVector []byte
+
+// QuantizeBinary packs the sign of each dimension into a BinaryVector.
+func QuantizeBinary(v Vector) BinaryVector {
+	b := make(BinaryVector, (len(v)+7)/8)
+	for i, x := range v {
+		if x > 0 {
+			b[i/8] |= 1 << (7 - uint(i%8))
+		}
+	}
+	return b
+}
+
+// Similarity returns 1 - normalized hamming distance, so that identical
+// vectors score 1 and completely opposite vectors score 0.
+func (b BinaryVector) Similarity(other BinaryVector, dimensions int) float64 {
+	var distance int
+	for i := range b {
+		distance += bits.OnesCount8(b[i] ^ other[i])
+	}
+	return 1 - float64(distance)/float64(dimensions)
+}